@@ -1,12 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	flag "github.com/spf13/pflag"
 	"maps"
 	"os"
+	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
+	"github.com/climbus/retro-romkit/pkg/checksum"
+	"github.com/climbus/retro-romkit/pkg/dat"
 	"github.com/climbus/retro-romkit/pkg/tosec"
 )
 
@@ -18,10 +25,21 @@ Available commands:
 	show <path>		Show file tree of the specified path
 	stats <path>		Show statistics about files in the specified path
 	list <path>		List all files in the specified path
-	copy <path>		Copy files from the specified path to the output directory
+	copy <path>		Copy files to the output directory, optionally extracting archives (--output <dir>)
+	lint <path>		Check file names against the TOSEC naming convention
+	rename <path>		Repair near-compliant file names (with preview and undo log)
+	rename --undo <log>	Revert the renames recorded in an undo log
+	verify <path>		Verify files against a DAT file (--dat <file>)
+	rebuild <path>		Rebuild files matched by checksum against a DAT (--dat <file> --output <dir>)
+	pack <path>		Pack files into TorrentZip archives (--output <dir>, --games, --split, --check to verify)
+	dat create <path>	Create a Logiqx XML DAT describing the files in the specified path
+	dat diff <old> <new>	Show the differences between two DAT releases (--apply <path> to rename files)
 	help			Show this help message`)
 }
 
+// readArchives is shared by all commands reading a folder.
+var readArchives = flag.Bool("archives", false, "Read the files inside archives instead of the archives themselves")
+
 func parsePlatformFlag() string {
 	platform := flag.StringP("platform", "p", "", "Platform to filter by (optional)")
 	flag.Parse()
@@ -39,7 +57,7 @@ func main() {
 		path := getPath()
 		platform := parsePlatformFlag()
 
-		tosecFolder := tosec.Create(path, platform)
+		tosecFolder := createFolder(path, platform)
 
 		lines := tosecFolder.FormatTree()
 		for line := range lines {
@@ -49,7 +67,7 @@ func main() {
 		path := getPath()
 		platform := parsePlatformFlag()
 
-		tosecFolder := tosec.Create(path, platform)
+		tosecFolder := createFolder(path, platform)
 
 		stats, err := tosecFolder.GetStats()
 
@@ -63,7 +81,11 @@ func main() {
 		}
 	case "list":
 		path := getPath()
-		tosecFolder := tosec.Create(path, "c64")
+		platform := parsePlatformFlag()
+		if platform == "" {
+			platform = "c64"
+		}
+		tosecFolder := createFolder(path, platform)
 
 		files, err := tosecFolder.GetFiles()
 		if err != nil {
@@ -71,20 +93,168 @@ func main() {
 			return
 		}
 		for _, file := range files {
-			fmt.Printf("%s (%s) - %s - r:%s l:%s : %s\n", file.Title, file.Date, file.Publisher, file.Region, file.Language, file.FileName)
+			fmt.Printf("%s (%s) - %s - r:%s l:%s : %s\n", file.Title, file.Date, file.Publisher, file.CountryCodes(), file.LanguageCodes(), file.FileName)
 		}
 	case "copy":
 		path := getPath()
-		platform := parsePlatformFlag()
-		tosecFolder := tosec.Create(path, platform)
-
-		// outputDir := flag.StringP("output", "o", "", "Output directory to copy files to")
+		output := flag.StringP("output", "o", "", "Output directory to copy files to")
 		limit := flag.IntP("limit", "l", 0, "Limit the number of files per directory")
 		unzip := flag.BoolP("unzip", "u", false, "Unzip files before copying")
-		flag.Parse()
+		yes := flag.BoolP("yes", "y", false, "Copy without asking for confirmation")
+		platform := parsePlatformFlag()
+		if *output == "" {
+			fmt.Println("Error: 'copy' command requires --output argument.")
+			os.Exit(1)
+		}
 
-		tosecFolder.BuildTree(tosec.CopyOptions{Limit: *limit, Unzip: *unzip})
+		tosecFolder := createFolder(path, platform)
+		opts := tosec.CopyOptions{Output: *output, Limit: *limit, Unzip: *unzip}
+		if err := copyFiles(tosecFolder, opts, *yes); err != nil {
+			fmt.Printf("Error copying files: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "lint":
+		path := getPath()
+		format := flag.StringP("format", "f", "text", "Output format: text or json")
+		platform := parsePlatformFlag()
+		tosecFolder := createFolder(path, platform)
 
+		results, err := tosecFolder.Lint()
+		if err != nil {
+			fmt.Printf("Error linting files: %v\n", err)
+			os.Exit(1)
+		}
+		if err := printLintResults(results, *format); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if slices.ContainsFunc(results, tosec.LintResult.HasErrors) {
+			os.Exit(1)
+		}
+	case "rename":
+		yes := flag.BoolP("yes", "y", false, "Apply renames without asking for confirmation")
+		undoLogPath := flag.String("undo-log", "", "Path of the undo log (default: .romkit-rename-<time>.log in <path>)")
+		undo := flag.String("undo", "", "Revert the renames recorded in the given undo log")
+		platform := parsePlatformFlag()
+
+		// The undo log records absolute paths, so undoing needs no <path>
+		if *undo != "" {
+			if err := undoRenames(*undo); err != nil {
+				fmt.Printf("Error undoing renames: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		tosecFolder := createFolder(getPath(), platform)
+		if err := renameFiles(tosecFolder, *undoLogPath, *yes); err != nil {
+			fmt.Printf("Error renaming files: %v\n", err)
+			os.Exit(1)
+		}
+	case "verify":
+		path := getPath()
+		datPaths := flag.StringArray("dat", nil, "DAT file to verify against (repeatable)")
+		format := flag.StringP("format", "f", "text", "Output format: text or json")
+		verbose := flag.BoolP("verbose", "v", false, "Also list files which are present")
+		fixdatDir := flag.String("fixdat", "", "Directory to write a fixdat of the missing roms of each DAT to")
+		missingPath := flag.String("missing", "", "File to write the list of missing roms to, grouped by DAT")
+		checksums := flag.Bool("checksums", false, "Match files by checksum instead of by name")
+		headerPath := flag.String("header", "", "Header detector XML used when hashing (default: the platform's detector)")
+		platform := parsePlatformFlag()
+		if len(*datPaths) == 0 {
+			fmt.Println("Error: 'verify' command requires a --dat argument.")
+			os.Exit(1)
+		}
+
+		datafiles, err := loadDats(*datPaths)
+		if err != nil {
+			fmt.Printf("Error loading DAT: %v\n", err)
+			os.Exit(1)
+		}
+		tosecFolder := createFolder(path, platform)
+		setHeaderDetector(tosecFolder, *headerPath)
+		verify := tosecFolder.Verify
+		if *checksums {
+			verify = tosecFolder.VerifyChecksums
+		}
+		report, err := verify(datafiles...)
+		if err != nil {
+			fmt.Printf("Error verifying files: %v\n", err)
+			os.Exit(1)
+		}
+		if err := printVerifyReport(report, *format, *verbose); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeMissing(report, datafiles, *fixdatDir, *missingPath); err != nil {
+			fmt.Printf("Error writing missing roms: %v\n", err)
+			os.Exit(1)
+		}
+	case "rebuild":
+		path := getPath()
+		datPath := flag.String("dat", "", "DAT file to rebuild against")
+		output := flag.StringP("output", "o", "", "Output directory for rebuilt files")
+		unknownDir := flag.String("unknown", "", "Directory for files not in the DAT (default: <output>/unknown)")
+		move := flag.Bool("move", false, "Move files instead of copying them")
+		yes := flag.BoolP("yes", "y", false, "Rebuild without asking for confirmation")
+		headerPath := flag.String("header", "", "Header detector XML used when hashing (default: the platform's detector)")
+		platform := parsePlatformFlag()
+		if *datPath == "" || *output == "" {
+			fmt.Println("Error: 'rebuild' command requires --dat and --output arguments.")
+			os.Exit(1)
+		}
+		if *unknownDir == "" {
+			*unknownDir = filepath.Join(*output, "unknown")
+		}
+
+		datafile, err := dat.Load(*datPath)
+		if err != nil {
+			fmt.Printf("Error loading DAT: %v\n", err)
+			os.Exit(1)
+		}
+		tosecFolder := createFolder(path, platform)
+		setHeaderDetector(tosecFolder, *headerPath)
+		if err := rebuildFiles(tosecFolder, datafile, *output, *unknownDir, *move, *yes); err != nil {
+			fmt.Printf("Error rebuilding files: %v\n", err)
+			os.Exit(1)
+		}
+	case "pack":
+		path := getPath()
+		output := flag.StringP("output", "o", "", "Output directory for the TorrentZip archives")
+		yes := flag.BoolP("yes", "y", false, "Pack without asking for confirmation")
+		check := flag.Bool("check", false, "Only check whether the zip archives are TorrentZipped")
+		games := flag.Bool("games", false, "Pack all media of a game into one archive named after the game")
+		split := flag.Bool("split", false, "Pack every file, including archive members, into its own archive")
+		platform := parsePlatformFlag()
+		tosecFolder := createFolder(path, platform)
+		if *check {
+			if err := checkTorrentZip(tosecFolder); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if *output == "" {
+			fmt.Println("Error: 'pack' command requires --output argument.")
+			os.Exit(1)
+		}
+		opts := tosec.PackOptions{Output: *output, Mode: tosec.PackFiles}
+		switch {
+		case *games && *split:
+			fmt.Println("Error: --games and --split cannot be combined.")
+			os.Exit(1)
+		case *games:
+			opts.Mode = tosec.PackGames
+		case *split:
+			opts.Mode = tosec.PackSplit
+		}
+		if err := packFiles(tosecFolder, opts, *yes); err != nil {
+			fmt.Printf("Error packing files: %v\n", err)
+			os.Exit(1)
+		}
+	case "dat":
+		runDatCommand()
 	case "help":
 		printUsage()
 	default:
@@ -93,14 +263,457 @@ func main() {
 	}
 }
 
-func getPath() string {
+func printLintResults(results []tosec.LintResult, format string) error {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "text":
+		for _, result := range results {
+			fmt.Println(result.Path)
+			for _, v := range result.Violations {
+				fmt.Printf("  %-7s %s: %s\n", v.Severity, v.Rule, v.Message)
+				if v.Suggestion != "" {
+					fmt.Printf("          fix: %s\n", v.Suggestion)
+				}
+			}
+		}
+		fmt.Printf("%d file(s) with violations\n", len(results))
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+	return nil
+}
+
+func printVerifyReport(report tosec.VerifyReport, format string, verbose bool) error {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(report.Results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "text":
+		for _, result := range report.Results {
+			switch result.Status {
+			case tosec.StatusHave:
+				if verbose {
+					fmt.Printf("%-8s %s\n", result.Status, result.Path)
+				}
+			case tosec.StatusMissing:
+				fmt.Printf("%-8s %s\n", result.Status, result.Rom)
+			case tosec.StatusBadName:
+				fmt.Printf("%-8s %s\n         should be: %s\n", result.Status, result.Path, result.Rom)
+			case tosec.StatusUnknown:
+				if result.Error != "" {
+					fmt.Printf("%-8s %s: %s\n", result.Status, result.Path, result.Error)
+				} else {
+					fmt.Printf("%-8s %s\n", result.Status, result.Path)
+				}
+			default:
+				fmt.Printf("%-8s %s\n", result.Status, result.Path)
+			}
+		}
+		fmt.Printf("have: %d, missing: %d, unknown: %d, bad-name: %d\n",
+			report.Count(tosec.StatusHave), report.Count(tosec.StatusMissing),
+			report.Count(tosec.StatusUnknown), report.Count(tosec.StatusBadName))
+	default:
+		return fmt.Errorf("unknown output format '%s'", format)
+	}
+	return nil
+}
+
+func renameFiles(tosecFolder *tosec.Folder, undoLogPath string, yes bool) error {
+	renames, parseErrors, err := tosecFolder.PlanRenames()
+	if err != nil {
+		return err
+	}
+	return applyRenamePlan(tosecFolder, renames, parseErrors, undoLogPath, yes)
+}
+
+func applyRenamePlan(tosecFolder *tosec.Folder, renames []tosec.Rename, parseErrors []tosec.ParseError, undoLogPath string, yes bool) error {
+	for _, pe := range parseErrors {
+		fmt.Printf("Skipping %s: %v\n", pe.FileName, pe.Error)
+	}
+	if len(renames) == 0 {
+		fmt.Println("Nothing to rename.")
+		return nil
+	}
+	for _, r := range renames {
+		fmt.Printf("%s\n- %s\n+ %s\n", r.Dir, r.From, r.To)
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Apply %d rename(s)?", len(renames))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	if undoLogPath == "" {
+		undoLogPath = filepath.Join(tosecFolder.Path, fmt.Sprintf(".romkit-rename-%s.log", time.Now().Format("20060102-150405")))
+	}
+	undoLog, err := os.Create(undoLogPath)
+	if err != nil {
+		return err
+	}
+	defer undoLog.Close()
+
+	if err := tosec.ApplyRenames(renames, undoLog); err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %d file(s). Undo log: %s\n", len(renames), undoLogPath)
+	return nil
+}
+
+// createFolder creates the folder for the command, applying the shared flags.
+func createFolder(path, platform string) *tosec.Folder {
+	tosecFolder := tosec.Create(path, platform)
+	tosecFolder.ReadArchives = *readArchives
+	return tosecFolder
+}
+
+func setHeaderDetector(tosecFolder *tosec.Folder, headerPath string) {
+	if headerPath == "" {
+		return
+	}
+	detector, err := checksum.LoadDetector(headerPath)
+	if err != nil {
+		fmt.Printf("Error loading header detector: %v\n", err)
+		os.Exit(1)
+	}
+	tosecFolder.Detector = detector
+}
+
+func loadDats(paths []string) ([]*dat.Datafile, error) {
+	datafiles := make([]*dat.Datafile, 0, len(paths))
+	for _, path := range paths {
+		datafile, err := dat.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		datafiles = append(datafiles, datafile)
+	}
+	return datafiles, nil
+}
+
+func writeMissing(report tosec.VerifyReport, datafiles []*dat.Datafile, fixdatDir, missingPath string) error {
+	if fixdatDir != "" {
+		if err := os.MkdirAll(fixdatDir, 0755); err != nil {
+			return err
+		}
+		written := make(map[string]bool)
+		for i, datafile := range datafiles {
+			fixdat := report.Fixdat(datafile)
+			if len(fixdat.Games) == 0 {
+				continue
+			}
+			name := strings.ReplaceAll(fixdat.Header.Name, string(os.PathSeparator), "_")
+			if written[name] {
+				// DATs sharing a name get a fixdat each
+				name = fmt.Sprintf("%s (%d)", name, i+1)
+			}
+			written[name] = true
+			fixdatPath := filepath.Join(fixdatDir, name+".dat")
+			if err := writeDatFile(fixdatPath, fixdat); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote fixdat with %d game(s) to %s\n", len(fixdat.Games), fixdatPath)
+		}
+	}
+
+	if missingPath != "" {
+		f, err := os.Create(missingPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := report.WriteMissingList(f); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote missing list to %s\n", missingPath)
+	}
+	return nil
+}
+
+func writeDatFile(path string, datafile *dat.Datafile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return dat.WriteXML(f, datafile)
+}
+
+func copyFiles(tosecFolder *tosec.Folder, opts tosec.CopyOptions, yes bool) error {
+	actions, skipped, err := tosecFolder.PlanCopy(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range skipped {
+		fmt.Printf("Skipping %s: %v\n", s.FileName, s.Error)
+	}
+	if len(actions) == 0 {
+		fmt.Println("Nothing to copy.")
+		return nil
+	}
+	for _, a := range actions {
+		source := a.Source
+		if a.Member != "" {
+			source += ":" + a.Member
+		}
+		fmt.Printf("%s\n> %s\n", source, a.Target)
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Copy %d file(s)?", len(actions))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	if err := tosec.ApplyCopy(actions); err != nil {
+		return err
+	}
+	fmt.Printf("Copied %d file(s).\n", len(actions))
+	return nil
+}
+
+func packFiles(tosecFolder *tosec.Folder, opts tosec.PackOptions, yes bool) error {
+	actions, skipped, err := tosecFolder.PlanPack(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range skipped {
+		fmt.Printf("Warning: %s: %v\n", s.FileName, s.Error)
+	}
+	if len(actions) == 0 {
+		fmt.Println("Nothing to pack.")
+		return nil
+	}
+	for _, a := range actions {
+		fmt.Println(a.Target)
+		for _, source := range a.Sources {
+			fmt.Printf("  %s\n", source.Name)
+		}
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Write %d archive(s)?", len(actions))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	if err := tosec.ApplyPack(actions); err != nil {
+		return err
+	}
+	fmt.Printf("Packed %d archive(s).\n", len(actions))
+	return nil
+}
+
+// checkTorrentZip reports the zip archives which are not TorrentZipped and
+// exits with status 1 if there are any.
+func checkTorrentZip(tosecFolder *tosec.Folder) error {
+	checked, failed, err := tosecFolder.VerifyTorrentZip()
+	if err != nil {
+		return err
+	}
+	for _, f := range failed {
+		fmt.Printf("%s: %v\n", f.FileName, f.Error)
+	}
+	fmt.Printf("%d of %d archive(s) TorrentZipped.\n", checked-len(failed), checked)
+	if len(failed) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func rebuildFiles(tosecFolder *tosec.Folder, datafile *dat.Datafile, output, unknownDir string, move, yes bool) error {
+	actions, skipped, err := tosecFolder.PlanRebuild(datafile, output, unknownDir)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range skipped {
+		fmt.Printf("Skipping %s: %v\n", s.FileName, s.Error)
+	}
+	if len(actions) == 0 {
+		fmt.Println("Nothing to rebuild.")
+		return nil
+	}
+	unknown := 0
+	for _, a := range actions {
+		source := a.Source
+		if a.Member != "" {
+			source += ":" + a.Member
+		}
+		if a.Unknown {
+			unknown++
+		}
+		fmt.Printf("%s\n> %s\n", source, a.Target)
+	}
+
+	verb := "Copy"
+	if move {
+		verb = "Move"
+	}
+	if !yes && !confirm(fmt.Sprintf("%s %d file(s)?", verb, len(actions))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	if err := tosec.ApplyRebuild(actions, move); err != nil {
+		return err
+	}
+	fmt.Printf("Rebuilt %d file(s), %d unknown.\n", len(actions)-unknown, unknown)
+	return nil
+}
+
+func undoRenames(undoLogPath string) error {
+	undoLog, err := os.Open(undoLogPath)
+	if err != nil {
+		return err
+	}
+	defer undoLog.Close()
+
+	if err := tosec.UndoRenames(undoLog); err != nil {
+		return err
+	}
+	fmt.Println("Renames reverted.")
+	return nil
+}
+
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func runDatCommand() {
 	if len(os.Args) < 3 {
-		fmt.Println("Error: '" + os.Args[1] + "' command requires a path argument.\n")
+		fmt.Print("Error: 'dat' command requires a subcommand.\n\n")
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "create":
+		path := getPathArg(3)
+		header := dat.Header{}
+		flag.StringVar(&header.Name, "name", filepath.Base(path), "DAT name")
+		flag.StringVar(&header.Description, "description", "", "DAT description (default: the DAT name)")
+		flag.StringVar(&header.Version, "version", time.Now().Format("2006-01-02"), "DAT version")
+		flag.StringVar(&header.Author, "author", "", "DAT author")
+		output := flag.StringP("output", "o", "", "File to write the DAT to (default: standard output)")
+		headerPath := flag.String("header", "", "Header detector XML used when hashing (default: the platform's detector)")
+		platform := parsePlatformFlag()
+		if header.Description == "" {
+			header.Description = header.Name
+		}
+
+		tosecFolder := createFolder(path, platform)
+		setHeaderDetector(tosecFolder, *headerPath)
+		if err := createDat(tosecFolder, header, *output); err != nil {
+			fmt.Printf("Error creating DAT: %v\n", err)
+			os.Exit(1)
+		}
+	case "diff":
+		if len(os.Args) < 5 {
+			fmt.Print("Error: 'dat diff' command requires old and new DAT arguments.\n\n")
+			printUsage()
+			os.Exit(1)
+		}
+		apply := flag.String("apply", "", "Collection to apply the renames to")
+		yes := flag.BoolP("yes", "y", false, "Apply renames without asking for confirmation")
+		undoLogPath := flag.String("undo-log", "", "Path of the undo log (default: .romkit-rename-<time>.log in the collection)")
+		platform := parsePlatformFlag()
+		if *apply != "" {
+			validatePath(*apply)
+		}
+
+		datafiles, err := loadDats(os.Args[3:5])
+		if err != nil {
+			fmt.Printf("Error loading DAT: %v\n", err)
+			os.Exit(1)
+		}
+		diff := dat.Compare(datafiles[0], datafiles[1])
+		printDatDiff(diff)
+		if *apply == "" {
+			return
+		}
+
+		tosecFolder := createFolder(*apply, platform)
+		renames, skipped, err := tosecFolder.PlanDatRenames(diff)
+		if err == nil {
+			err = applyRenamePlan(tosecFolder, renames, skipped, *undoLogPath, *yes)
+		}
+		if err != nil {
+			fmt.Printf("Error renaming files: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown dat subcommand: %s\n\n", os.Args[2])
+		printUsage()
+	}
+}
+
+func createDat(tosecFolder *tosec.Folder, header dat.Header, output string) error {
+	datafile, parseErrors, err := tosecFolder.CreateDat(header)
+	if err != nil {
+		return err
+	}
+	for _, pe := range parseErrors {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", pe.FileName, pe.Error)
+	}
+
+	if output == "" {
+		return dat.WriteXML(os.Stdout, datafile)
+	}
+	if err := writeDatFile(output, datafile); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d game(s) to %s\n", len(datafile.Games), output)
+	return nil
+}
+
+func printDatDiff(diff dat.Diff) {
+	for _, rom := range diff.Added {
+		fmt.Printf("added    %s\n", rom.Name)
+	}
+	for _, rom := range diff.Removed {
+		fmt.Printf("removed  %s\n", rom.Name)
+	}
+	for _, change := range diff.Renamed {
+		fmt.Printf("renamed  %s\n      -> %s\n", change.Old.Name, change.New.Name)
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf("changed  %s (crc %s -> %s)\n", change.New.Name, change.Old.CRC, change.New.CRC)
+	}
+	for _, change := range diff.RenamedGames {
+		fmt.Printf("game     %s\n      -> %s\n", change.Old.Name, change.New.Name)
+	}
+	fmt.Printf("added: %d, removed: %d, renamed: %d, changed: %d, renamed games: %d\n",
+		len(diff.Added), len(diff.Removed), len(diff.Renamed), len(diff.Changed), len(diff.RenamedGames))
+}
+
+func getPath() string {
+	return getPathArg(2)
+}
+
+func getPathArg(index int) string {
+	if len(os.Args) <= index {
+		fmt.Println("Error: '" + strings.Join(os.Args[1:index], " ") + "' command requires a path argument.\n")
 		printUsage()
 		os.Exit(1)
 	}
-	path := os.Args[2]
+	path := os.Args[index]
+	validatePath(path)
+	return path
+}
 
+// validatePath exits unless the path is an existing directory.
+func validatePath(path string) {
 	// Validate that the path exists
 	info, err := os.Stat(path)
 	if err != nil {
@@ -117,6 +730,4 @@ func getPath() string {
 		fmt.Printf("Error: Path '%s' is not a directory.\n", path)
 		os.Exit(1)
 	}
-
-	return path
 }
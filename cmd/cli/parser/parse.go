@@ -13,4 +13,5 @@ func main() {
 		return
 	}
 	fmt.Println("Parsed Tosec File: ", *tf)
+	fmt.Println("Canonical name: ", tosec.FormatFileName(tf))
 }
@@ -0,0 +1,104 @@
+package tree
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/climbus/retro-romkit/pkg/checksum"
+)
+
+// Hash computes the checksums of the file entries received from in with the
+// hasher and sends them to out with Sums set, using at most workers
+// concurrent readers.
+// Directory entries are passed through unchanged. Consecutive members of
+// the same archive are hashed together, reading the archive once. Entries
+// may be sent in a different order than received. Files which cannot be
+// hashed are sent without checksums and with Err set, and the first such
+// error is returned.
+func Hash(in <-chan Entry, hasher checksum.Hasher, workers int, out chan<- Entry) error {
+	defer close(out)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	groups := make(chan []Entry, workers)
+	go groupArchiveMembers(in, groups)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range groups {
+				hashGroup(hasher, group)
+				for _, entry := range group {
+					if entry.Err != nil {
+						once.Do(func() { firstErr = entry.Err })
+					}
+					out <- entry
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// groupArchiveMembers sends the consecutive members of an archive as one
+// group and every other entry on its own.
+func groupArchiveMembers(in <-chan Entry, groups chan<- []Entry) {
+	defer close(groups)
+
+	var group []Entry
+	for entry := range in {
+		if len(group) > 0 && (entry.Archive == "" || entry.Archive != group[0].Archive) {
+			groups <- group
+			group = nil
+		}
+		if entry.Archive == "" {
+			groups <- []Entry{entry}
+			continue
+		}
+		group = append(group, entry)
+	}
+	if len(group) > 0 {
+		groups <- group
+	}
+}
+
+// hashGroup sets the checksums, or the error, of the file entries of a
+// group, which is either a single entry or members of the same archive.
+func hashGroup(hasher checksum.Hasher, group []Entry) {
+	if group[0].IsDir {
+		return
+	}
+	if group[0].Archive == "" {
+		group[0].Sums, group[0].Err = hasher.File(group[0].Path)
+		return
+	}
+
+	members, err := hasher.Archive(group[0].Archive)
+	if err != nil {
+		for i := range group {
+			group[i].Err = err
+		}
+		return
+	}
+	byName := make(map[string]checksum.Sums, len(members))
+	for _, sums := range members {
+		byName[sums.Name] = sums
+	}
+	for i := range group {
+		sums, ok := byName[group[i].Path]
+		if !ok {
+			group[i].Err = fmt.Errorf("'%s' not found in '%s'", group[i].Path, group[i].Archive)
+			continue
+		}
+		group[i].Sums = []checksum.Sums{sums}
+	}
+}
@@ -2,9 +2,14 @@
 package tree
 
 import (
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/climbus/retro-romkit/pkg/archive"
+	"github.com/climbus/retro-romkit/pkg/checksum"
 )
 
 // Entry represents a single tree entry
@@ -13,9 +18,27 @@ type Entry struct {
 	Depth  int
 	IsDir  bool
 	Folder string
+	// Path is the path of the entry on disk, including the walked root. For
+	// archive members it is the path of the member inside the archive.
+	Path    string
+	Archive string          // Path of the containing archive on disk, empty for plain files
+	Sums    []checksum.Sums // Checksums of the file contents, set by Hash
+	Err     error           // Error hashing the file, set by Hash
+}
+
+// Options configures a walk.
+type Options struct {
+	FileTypes []string
+	// Archives makes the walk descend into archives of every format read by
+	// the archive package. An archive is then sent as a directory entry,
+	// followed by one entry per contained file. Archives which cannot be
+	// read are sent as plain files.
+	Archives bool
 }
 
-func hasOneOfFileTypes(file string, filetypes []string) bool {
+// HasOneOfFileTypes reports whether the file name ends with one of the file
+// types. All names match when no file types are given.
+func HasOneOfFileTypes(file string, filetypes []string) bool {
 	if len(filetypes) == 0 {
 		return true // No file types specified, so no files to skip
 	}
@@ -29,6 +52,11 @@ func hasOneOfFileTypes(file string, filetypes []string) bool {
 
 // Walk traverses the directory tree and sends entries to the provided channel
 func Walk(path string, filetypes []string, entries chan<- Entry) error {
+	return WalkWithOptions(path, Options{FileTypes: filetypes}, entries)
+}
+
+// WalkWithOptions traverses the directory tree like Walk, configured by opts.
+func WalkWithOptions(path string, opts Options, entries chan<- Entry) error {
 	defer close(entries)
 
 	err := filepath.WalkDir(path, func(file string, info os.DirEntry, err error) error {
@@ -42,7 +70,7 @@ func Walk(path string, filetypes []string, entries chan<- Entry) error {
 			return nil // Skip the root directory itself
 		}
 
-		if !hasOneOfFileTypes(file, filetypes) && !info.IsDir() {
+		if !HasOneOfFileTypes(file, opts.FileTypes) && !info.IsDir() {
 			return nil // Skip files that don't match the specified file types
 		}
 
@@ -52,6 +80,14 @@ func Walk(path string, filetypes []string, entries chan<- Entry) error {
 		}
 		depth := len(strings.Split(relFilename, string(os.PathSeparator))) - 1
 
+		if opts.Archives && !info.IsDir() && archive.IsArchive(file) {
+			if members, err := archiveEntries(file, relFilename, depth, opts.FileTypes); err == nil {
+				sendArchive(file, relFilename, depth, members, entries)
+				return nil
+			}
+			// Unreadable archives are sent as plain files below
+		}
+
 		var name string
 		if info.IsDir() {
 			name = relFilename
@@ -65,6 +101,7 @@ func Walk(path string, filetypes []string, entries chan<- Entry) error {
 			Depth:  depth,
 			IsDir:  info.IsDir(),
 			Folder: folder,
+			Path:   file,
 		}
 
 		return nil
@@ -72,3 +109,46 @@ func Walk(path string, filetypes []string, entries chan<- Entry) error {
 
 	return err
 }
+
+// archiveEntries lists the members of an archive as entries. Nothing is
+// sent until the whole archive has been read, so that a damaged archive
+// can still be sent as a plain file.
+func archiveEntries(file, relFilename string, depth int, filetypes []string) ([]Entry, error) {
+	var members []Entry
+	err := archive.Walk(file, func(member archive.File, _ io.Reader) error {
+		if !HasOneOfFileTypes(member.Name, filetypes) {
+			return nil
+		}
+		memberDir := path.Dir(member.Name)
+		folder := relFilename
+		memberDepth := depth + 1
+		if memberDir != "." {
+			folder = filepath.Join(relFilename, filepath.FromSlash(memberDir))
+			memberDepth += strings.Count(memberDir, "/") + 1
+		}
+
+		members = append(members, Entry{
+			Name:    path.Base(member.Name),
+			Depth:   memberDepth,
+			Folder:  folder,
+			Path:    member.Name,
+			Archive: file,
+		})
+		return nil
+	})
+	return members, err
+}
+
+// sendArchive sends the archive as a directory entry followed by its members.
+func sendArchive(file, relFilename string, depth int, members []Entry, entries chan<- Entry) {
+	entries <- Entry{
+		Name:   relFilename,
+		Depth:  depth,
+		IsDir:  true,
+		Folder: filepath.Dir(relFilename),
+		Path:   file,
+	}
+	for _, member := range members {
+		entries <- member
+	}
+}
@@ -1,7 +1,10 @@
 package tree
 
 import (
+	"github.com/climbus/retro-romkit/pkg/checksum"
 	"github.com/climbus/retro-romkit/testutils"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -90,3 +93,109 @@ func TestWalk(t *testing.T) {
 		}
 	})
 }
+
+func TestHash(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{"a.d64", "subdir/b.d64", "subdir/c.d64"}, tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.d64"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	walked := make(chan Entry, 100)
+	hashed := make(chan Entry, 100)
+	go func() {
+		if err := Walk(tmpDir, []string{".d64"}, walked); err != nil {
+			t.Errorf("Walk() error = %v", err)
+		}
+	}()
+	go func() {
+		if err := Hash(walked, checksum.Hasher{}, 2, hashed); err != nil {
+			t.Errorf("Hash() error = %v", err)
+		}
+	}()
+
+	files := 0
+	for entry := range hashed {
+		if entry.IsDir {
+			if entry.Sums != nil {
+				t.Errorf("directory %q has checksums", entry.Name)
+			}
+			continue
+		}
+		files++
+		if len(entry.Sums) != 1 {
+			t.Fatalf("entry %q has %d checksums, want 1", entry.Name, len(entry.Sums))
+		}
+		want := "00000000"
+		if entry.Name == "a.d64" {
+			want = "3610a686"
+		}
+		if entry.Sums[0].CRC32 != want {
+			t.Errorf("entry %q CRC32 = %s, want %s", entry.Name, entry.Sums[0].CRC32, want)
+		}
+	}
+	if files != 3 {
+		t.Errorf("Hash() sent %d files, want 3", files)
+	}
+}
+
+func TestWalkArchives(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{"a.d64"}, tmpDir)
+	if err := os.WriteFile(filepath.Join(tmpDir, "broken.zip"), []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(tmpDir, "sets", "game.zip")
+	files := make(map[string]string)
+	for _, name := range []string{"disk1.d64", "extras/disk2.d64", "readme.txt"} {
+		files[name] = name
+	}
+	testutils.CreateTestZip(t, archive, files)
+
+	entries := make(chan Entry, 100)
+	go func() {
+		if err := WalkWithOptions(tmpDir, Options{FileTypes: []string{".d64", ".zip"}, Archives: true}, entries); err != nil {
+			t.Errorf("WalkWithOptions() error = %v", err)
+		}
+	}()
+
+	var got []Entry
+	for entry := range entries {
+		got = append(got, entry)
+	}
+
+	want := []Entry{
+		{Name: "a.d64", Depth: 0, Folder: ".", Path: filepath.Join(tmpDir, "a.d64")},
+		{Name: "broken.zip", Depth: 0, Folder: ".", Path: filepath.Join(tmpDir, "broken.zip")},
+		{Name: "sets", Depth: 0, IsDir: true, Folder: ".", Path: filepath.Join(tmpDir, "sets")},
+		{Name: filepath.Join("sets", "game.zip"), Depth: 1, IsDir: true, Folder: "sets", Path: archive},
+		{Name: "disk1.d64", Depth: 2, Folder: filepath.Join("sets", "game.zip"), Path: "disk1.d64", Archive: archive},
+		{Name: "disk2.d64", Depth: 3, Folder: filepath.Join("sets", "game.zip", "extras"), Path: "extras/disk2.d64", Archive: archive},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkWithOptions() = %+v, want %+v", got, want)
+	}
+
+	walked := make(chan Entry, 100)
+	hashed := make(chan Entry, 100)
+	go func() {
+		for _, entry := range want {
+			if entry.Name != "broken.zip" {
+				walked <- entry
+			}
+		}
+		close(walked)
+	}()
+	if err := Hash(walked, checksum.Hasher{}, 1, hashed); err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	for entry := range hashed {
+		if entry.Archive != "" && (len(entry.Sums) != 1 || entry.Sums[0].Size != int64(len(entry.Path))) {
+			t.Errorf("Hash() %q sums = %+v, want the member checksums", entry.Path, entry.Sums)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package archive reads the files contained in zip, gzip, tar, 7z and RAR
+// archives through a common Reader.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is an archive format, detected from the file extension.
+type Format string
+
+const (
+	Zip      Format = "zip"
+	Gzip     Format = "gzip"
+	Tar      Format = "tar"
+	TarGzip  Format = "tar.gz"
+	SevenZip Format = "7z"
+	Rar      Format = "rar"
+)
+
+// ErrUnsupportedFormat is returned for files which are not a supported archive.
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// File is a regular file contained in an archive.
+type File struct {
+	Name string // Slash-separated path inside the archive
+	Size int64  // Uncompressed size, -1 when unknown
+}
+
+// Reader reads the regular files of an archive one by one, in archive
+// order. Directories, links and other special entries are skipped.
+type Reader interface {
+	// Next advances to the next file, returning io.EOF after the last one.
+	Next() (File, error)
+	// Read reads the contents of the current file.
+	io.Reader
+	io.Closer
+}
+
+// WalkFunc is called for every file of an archive. The reader holds the
+// file contents and is only valid until the function returns.
+type WalkFunc func(f File, r io.Reader) error
+
+// DetectFormat returns the archive format of the path.
+func DetectFormat(path string) (Format, bool) {
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return Zip, true
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return TarGzip, true
+	case strings.HasSuffix(name, ".tar"):
+		return Tar, true
+	case strings.HasSuffix(name, ".gz"):
+		return Gzip, true
+	case strings.HasSuffix(name, ".7z"):
+		return SevenZip, true
+	case strings.HasSuffix(name, ".rar"):
+		return Rar, true
+	}
+	return "", false
+}
+
+// IsArchive reports whether the path has the extension of a supported archive.
+func IsArchive(path string) bool {
+	_, ok := DetectFormat(path)
+	return ok
+}
+
+// TrimExt returns the file name without its archive extension, e.g. "set"
+// for "set.tar.gz".
+func TrimExt(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip", ".gz", ".7z", ".rar"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Open opens the archive at path for reading.
+func Open(path string) (Reader, error) {
+	format, ok := DetectFormat(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedFormat, path)
+	}
+	switch format {
+	case Zip:
+		return openZip(path)
+	case SevenZip:
+		return openSevenZip(path)
+	case Rar:
+		return openRar(path)
+	default:
+		return openStream(path, format)
+	}
+}
+
+// Walk calls fn for every regular file contained in the archive, in archive
+// order. Contents are only decompressed when fn reads them.
+func Walk(path string, fn WalkFunc) error {
+	r, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		f, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(f, r); err != nil {
+			return err
+		}
+	}
+}
+
+// Extract writes the contents of the named member of the archive to w.
+func Extract(path, member string, w io.Writer) error {
+	found := false
+	err := Walk(path, func(f File, r io.Reader) error {
+		if found || f.Name != member {
+			return nil
+		}
+		found = true
+		_, err := io.Copy(w, r)
+		return err
+	})
+	if err == nil && !found {
+		return fmt.Errorf("'%s' not found in '%s'", member, path)
+	}
+	return err
+}
@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   Format
+		wantOk bool
+	}{
+		{"set.zip", Zip, true},
+		{"SET.ZIP", Zip, true},
+		{"set.tar", Tar, true},
+		{"set.tar.gz", TarGzip, true},
+		{"set.tgz", TarGzip, true},
+		{"game.d64.gz", Gzip, true},
+		{"set.7z", SevenZip, true},
+		{"set.rar", Rar, true},
+		{"game.d64", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := DetectFormat(tt.path)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("DetectFormat() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	files := map[string]string{"disk1.d64": "one", "extras/disk2.d64": "two"}
+
+	withDir := maps.Clone(files)
+	withDir["extras/"] = ""
+
+	testutils.CreateTestZip(t, filepath.Join(tmpDir, "set.zip"), withDir)
+	testutils.CreateTestTar(t, filepath.Join(tmpDir, "set.tar"), withDir, false)
+	testutils.CreateTestTar(t, filepath.Join(tmpDir, "set.tar.gz"), withDir, true)
+	testutils.CreateTestGzip(t, filepath.Join(tmpDir, "disk1.d64.gz"), "", "one")
+	testutils.CreateTestGzip(t, filepath.Join(tmpDir, "stored.gz"), "disk2.d64", "two")
+	writeSevenZip(t, filepath.Join(tmpDir, "set.7z"), files)
+	writeRar(t, filepath.Join(tmpDir, "set.rar"), files)
+
+	tests := []struct {
+		archive string
+		want    map[string]string
+	}{
+		{"set.zip", files},
+		{"set.tar", files},
+		{"set.tar.gz", files},
+		{"disk1.d64.gz", map[string]string{"disk1.d64": "one"}},
+		{"stored.gz", map[string]string{"disk2.d64": "two"}},
+		{"set.7z", files},
+		{"set.rar", files},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.archive, func(t *testing.T) {
+			got := make(map[string]string)
+			err := Walk(filepath.Join(tmpDir, tt.archive), func(f File, r io.Reader) error {
+				data, err := io.ReadAll(r)
+				got[f.Name] = string(data)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("Walk() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Walk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, name := range []string{"set.zip", "set.tar.gz", "set.7z", "set.rar"} {
+		var buf bytes.Buffer
+		if err := Extract(filepath.Join(tmpDir, name), "extras/disk2.d64", &buf); err != nil || buf.String() != "two" {
+			t.Errorf("Extract(%s) = %q, %v, want %q", name, buf.String(), err, "two")
+		}
+		if err := Extract(filepath.Join(tmpDir, name), "missing.d64", io.Discard); err == nil {
+			t.Errorf("Extract(%s) of a missing member succeeded", name)
+		}
+	}
+
+	if err := Walk(filepath.Join(tmpDir, "game.d64"), nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Walk() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+// writeSevenZip writes a 7z archive storing the files uncompressed in a
+// single solid stream.
+func writeSevenZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	names := slices.Sorted(maps.Keys(files))
+
+	var data, header bytes.Buffer
+	header.Write([]byte{0x01, 0x04}) // Header, MainStreamsInfo
+	for _, name := range names {
+		data.WriteString(files[name])
+	}
+	header.Write([]byte{0x06, 0x00, 0x01, 0x09, byte(data.Len()), 0x00}) // PackInfo
+	header.Write([]byte{0x07, 0x0b, 0x01, 0x00, 0x01, 0x01, 0x00})       // UnpackInfo, one Copy folder
+	header.Write([]byte{0x0c, byte(data.Len()), 0x00})                   // Folder unpack size
+	header.Write([]byte{0x08, 0x0d, byte(len(names)), 0x09})             // SubStreamsInfo
+	for _, name := range names[:len(names)-1] {
+		header.WriteByte(byte(len(files[name])))
+	}
+	header.Write([]byte{0x0a, 0x01})
+	for _, name := range names {
+		binary.Write(&header, binary.LittleEndian, crc32.ChecksumIEEE([]byte(files[name])))
+	}
+	header.Write([]byte{0x00, 0x00}) // End of SubStreamsInfo and MainStreamsInfo
+
+	var nameProp bytes.Buffer
+	nameProp.WriteByte(0x00) // Not external
+	for _, name := range names {
+		for _, r := range utf16.Encode([]rune(name + "\x00")) {
+			binary.Write(&nameProp, binary.LittleEndian, r)
+		}
+	}
+	header.Write([]byte{0x05, byte(len(names)), 0x11, byte(nameProp.Len())}) // FilesInfo, Names
+	header.Write(nameProp.Bytes())
+	header.Write([]byte{0x00, 0x00}) // End of FilesInfo and Header
+
+	start := make([]byte, 20)
+	binary.LittleEndian.PutUint64(start, uint64(data.Len()))
+	binary.LittleEndian.PutUint64(start[8:], uint64(header.Len()))
+	binary.LittleEndian.PutUint32(start[16:], crc32.ChecksumIEEE(header.Bytes()))
+
+	var out bytes.Buffer
+	out.Write([]byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0x00, 0x04})
+	binary.Write(&out, binary.LittleEndian, crc32.ChecksumIEEE(start))
+	out.Write(start)
+	out.Write(data.Bytes())
+	out.Write(header.Bytes())
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeRar writes a RAR 4 archive storing the files uncompressed.
+func writeRar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var out bytes.Buffer
+	out.Write([]byte{'R', 'a', 'r', '!', 0x1a, 0x07, 0x00})
+	writeRarBlock(&out, 0x73, 0x0000, make([]byte, 6)) // Archive header
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		content := files[name]
+		var fields bytes.Buffer
+		binary.Write(&fields, binary.LittleEndian, uint32(len(content))) // Packed size
+		binary.Write(&fields, binary.LittleEndian, uint32(len(content))) // Unpacked size
+		fields.WriteByte(0x00)                                           // MS-DOS host
+		binary.Write(&fields, binary.LittleEndian, crc32.ChecksumIEEE([]byte(content)))
+		binary.Write(&fields, binary.LittleEndian, uint32(0x21000000)) // DOS time
+		fields.Write([]byte{20, 0x30})                                 // Version, store method
+		binary.Write(&fields, binary.LittleEndian, uint16(len(name)))
+		binary.Write(&fields, binary.LittleEndian, uint32(0x20)) // Archive attribute
+		fields.WriteString(strings.ReplaceAll(name, "/", "\\"))
+		writeRarBlock(&out, 0x74, 0x8000, fields.Bytes())
+		out.WriteString(content)
+	}
+	writeRarBlock(&out, 0x7b, 0x4000, nil) // End of archive
+
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRarBlock(out *bytes.Buffer, kind byte, flags uint16, fields []byte) {
+	var block bytes.Buffer
+	block.WriteByte(kind)
+	binary.Write(&block, binary.LittleEndian, flags)
+	binary.Write(&block, binary.LittleEndian, uint16(7+len(fields)))
+	block.Write(fields)
+	binary.Write(out, binary.LittleEndian, uint16(crc32.ChecksumIEEE(block.Bytes())))
+	out.Write(block.Bytes())
+}
@@ -0,0 +1,50 @@
+package archive
+
+import (
+	"io"
+
+	"github.com/nwaples/rardecode"
+)
+
+type rarReader struct {
+	archive *rardecode.ReadCloser
+	current bool
+}
+
+func openRar(path string) (*rarReader, error) {
+	archive, err := rardecode.OpenReader(path, "")
+	if err != nil {
+		return nil, err
+	}
+	return &rarReader{archive: archive}, nil
+}
+
+func (rr *rarReader) Next() (File, error) {
+	rr.current = false
+	for {
+		header, err := rr.archive.Next()
+		if err != nil {
+			return File{}, err
+		}
+		if header.IsDir || !header.Mode().IsRegular() {
+			continue
+		}
+		rr.current = true
+		size := header.UnPackedSize
+		if header.UnKnownSize {
+			size = -1
+		}
+		return File{Name: header.Name, Size: size}, nil
+	}
+}
+
+func (rr *rarReader) Read(p []byte) (int, error) {
+	if !rr.current {
+		return 0, io.EOF
+	}
+	return rr.archive.Read(p)
+}
+
+func (rr *rarReader) Close() error {
+	return rr.archive.Close()
+}
@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"io"
+
+	"github.com/bodgit/sevenzip"
+)
+
+type sevenZipReader struct {
+	archive *sevenzip.ReadCloser
+	next    int
+	file    *sevenzip.File
+	rc      io.ReadCloser // Contents of file, opened on the first read
+}
+
+func openSevenZip(path string) (*sevenZipReader, error) {
+	archive, err := sevenzip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sevenZipReader{archive: archive}, nil
+}
+
+func (sr *sevenZipReader) Next() (File, error) {
+	sr.closeFile()
+	for sr.next < len(sr.archive.File) {
+		entry := sr.archive.File[sr.next]
+		sr.next++
+		if entry.Mode().IsRegular() {
+			sr.file = entry
+			return File{Name: entry.Name, Size: int64(entry.UncompressedSize)}, nil
+		}
+	}
+	return File{}, io.EOF
+}
+
+func (sr *sevenZipReader) Read(p []byte) (int, error) {
+	if sr.file == nil {
+		return 0, io.EOF
+	}
+	if sr.rc == nil {
+		rc, err := sr.file.Open()
+		if err != nil {
+			return 0, err
+		}
+		sr.rc = rc
+	}
+	return sr.rc.Read(p)
+}
+
+func (sr *sevenZipReader) Close() error {
+	sr.closeFile()
+	return sr.archive.Close()
+}
+
+func (sr *sevenZipReader) closeFile() {
+	if sr.rc != nil {
+		sr.rc.Close()
+	}
+	sr.file, sr.rc = nil, nil
+}
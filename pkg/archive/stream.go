@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// streamReader reads gzip and tar archives, which can only be read from
+// the start to the end.
+type streamReader struct {
+	file *os.File
+	gz   *gzip.Reader
+	tr   *tar.Reader // nil for a plain gzip file
+	name string      // Name of the file of a plain gzip file, empty once read
+	cur  io.Reader
+}
+
+func openStream(path string, format Format) (*streamReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sr := &streamReader{file: f}
+	if format == Tar {
+		sr.tr = tar.NewReader(f)
+		return sr, nil
+	}
+
+	if sr.gz, err = gzip.NewReader(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("invalid gzip archive '%s': %w", path, err)
+	}
+	if format == TarGzip {
+		sr.tr = tar.NewReader(sr.gz)
+	} else {
+		sr.name = gzipName(path, sr.gz.Name)
+	}
+	return sr, nil
+}
+
+func (sr *streamReader) Next() (File, error) {
+	sr.cur = nil
+	if sr.tr == nil {
+		if sr.name == "" {
+			return File{}, io.EOF
+		}
+		f := File{Name: sr.name, Size: -1}
+		sr.name, sr.cur = "", sr.gz
+		return f, nil
+	}
+
+	for {
+		header, err := sr.tr.Next()
+		if err != nil {
+			return File{}, err
+		}
+		if header.Typeflag == tar.TypeReg {
+			sr.cur = sr.tr
+			return File{Name: header.Name, Size: header.Size}, nil
+		}
+	}
+}
+
+func (sr *streamReader) Read(p []byte) (int, error) {
+	if sr.cur == nil {
+		return 0, io.EOF
+	}
+	return sr.cur.Read(p)
+}
+
+func (sr *streamReader) Close() error {
+	var err error
+	if sr.gz != nil {
+		err = sr.gz.Close()
+	}
+	return errors.Join(err, sr.file.Close())
+}
+
+// gzipName returns the name stored in the gzip header, or the archive name
+// without its extension when none is stored.
+func gzipName(path, stored string) string {
+	if stored != "" {
+		return filepath.Base(stored)
+	}
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
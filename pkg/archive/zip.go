@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"archive/zip"
+	"io"
+)
+
+type zipReader struct {
+	archive *zip.ReadCloser
+	next    int
+	file    *zip.File
+	rc      io.ReadCloser // Contents of file, opened on the first read
+}
+
+func openZip(path string) (*zipReader, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return &zipReader{archive: archive}, nil
+}
+
+func (zr *zipReader) Next() (File, error) {
+	zr.closeFile()
+	for zr.next < len(zr.archive.File) {
+		entry := zr.archive.File[zr.next]
+		zr.next++
+		if entry.Mode().IsRegular() {
+			zr.file = entry
+			return File{Name: entry.Name, Size: int64(entry.UncompressedSize64)}, nil
+		}
+	}
+	return File{}, io.EOF
+}
+
+func (zr *zipReader) Read(p []byte) (int, error) {
+	if zr.file == nil {
+		return 0, io.EOF
+	}
+	if zr.rc == nil {
+		rc, err := zr.file.Open()
+		if err != nil {
+			return 0, err
+		}
+		zr.rc = rc
+	}
+	return zr.rc.Read(p)
+}
+
+func (zr *zipReader) Close() error {
+	zr.closeFile()
+	return zr.archive.Close()
+}
+
+func (zr *zipReader) closeFile() {
+	if zr.rc != nil {
+		zr.rc.Close()
+	}
+	zr.file, zr.rc = nil, nil
+}
@@ -0,0 +1,189 @@
+// Package checksum computes CRC32, MD5 and SHA1 checksums of files and of the entries of archives, optionally skipping copier headers.
+package checksum
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/climbus/retro-romkit/pkg/archive"
+)
+
+// Sums holds the size and checksums of a single file. Checksums are
+// lowercase hex strings, as used in DAT files.
+type Sums struct {
+	Name  string
+	Size  int64
+	CRC32 string
+	MD5   string
+	SHA1  string
+	// Headerless holds the checksums without the copier header, nil when no
+	// header was detected.
+	Headerless *Sums
+}
+
+// Hasher computes checksums. When a Detector is set, files with a copier
+// header are additionally hashed without it.
+type Hasher struct {
+	Detector *Detector
+}
+
+// digest computes all checksums of the data written to it.
+type digest struct {
+	crc, md5, sha1 hash.Hash
+	size           int64
+}
+
+// rangeWriter passes on the part of the written data between start and
+// end, counted from the first byte written.
+type rangeWriter struct {
+	w          io.Writer
+	start, end int64
+	pos        int64
+}
+
+// Compute reads r to the end and returns its checksums. The content is
+// streamed, so memory use does not depend on its size.
+func Compute(name string, r io.Reader) (Sums, error) {
+	return Hasher{}.Compute(name, r, -1)
+}
+
+// File returns the checksums of the file at path. For archives it
+// returns the checksums of every contained file instead, named by their
+// path inside the archive.
+func File(path string) ([]Sums, error) {
+	return Hasher{}.File(path)
+}
+
+// Archive returns the checksums of every file contained in the archive.
+func Archive(path string) ([]Sums, error) {
+	return Hasher{}.Archive(path)
+}
+
+// Compute reads r to the end and returns its checksums. The size is used by
+// detector rules testing the file size and may be -1 when unknown.
+func (h Hasher) Compute(name string, r io.Reader, size int64) (Sums, error) {
+	full := newDigest()
+	if h.Detector == nil {
+		if _, err := io.Copy(full, r); err != nil {
+			return Sums{}, err
+		}
+		return full.sums(name), nil
+	}
+
+	head := make([]byte, h.Detector.headSize())
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Sums{}, err
+	}
+	head = head[:n]
+	r = io.MultiReader(bytes.NewReader(head), r)
+
+	rule, ok := h.Detector.Detect(head, size)
+	if !ok {
+		if _, err := io.Copy(full, r); err != nil {
+			return Sums{}, err
+		}
+		return full.sums(name), nil
+	}
+
+	headerless := newDigest()
+	w := io.MultiWriter(full, &rangeWriter{w: headerless, start: rule.StartOffset, end: rule.EndOffset})
+	if _, err := io.Copy(w, r); err != nil {
+		return Sums{}, err
+	}
+	sums := full.sums(name)
+	stripped := headerless.sums(name)
+	sums.Headerless = &stripped
+	return sums, nil
+}
+
+// File returns the checksums of the file at path, or of every file
+// contained in it for archives.
+func (h Hasher) File(path string) ([]Sums, error) {
+	if archive.IsArchive(path) {
+		return h.Archive(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	sums, err := h.Compute(filepath.Base(path), f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return []Sums{sums}, nil
+}
+
+// Archive returns the checksums of every file contained in the archive.
+func (h Hasher) Archive(path string) ([]Sums, error) {
+	var result []Sums
+	err := archive.Walk(path, func(f archive.File, r io.Reader) error {
+		sums, err := h.Compute(f.Name, r, f.Size)
+		if err != nil {
+			return err
+		}
+		result = append(result, sums)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func newDigest() *digest {
+	return &digest{crc: crc32.NewIEEE(), md5: md5.New(), sha1: sha1.New()}
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	// Hash writes never return errors
+	d.crc.Write(p)
+	d.md5.Write(p)
+	d.sha1.Write(p)
+	d.size += int64(len(p))
+	return len(p), nil
+}
+
+func (d *digest) sums(name string) Sums {
+	return Sums{
+		Name:  name,
+		Size:  d.size,
+		CRC32: hexSum(d.crc),
+		MD5:   hexSum(d.md5),
+		SHA1:  hexSum(d.sha1),
+	}
+}
+
+func (rw *rangeWriter) Write(p []byte) (int, error) {
+	from := max(rw.start-rw.pos, 0)
+	to := int64(len(p))
+	if rw.end != EOF {
+		to = min(to, rw.end-rw.pos)
+	}
+	rw.pos += int64(len(p))
+	if from < to {
+		if _, err := rw.w.Write(p[from:to]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func hexSum(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
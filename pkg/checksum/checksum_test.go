@@ -0,0 +1,82 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Sums
+	}{
+		{"empty", "", Sums{
+			Name:  "empty",
+			Size:  0,
+			CRC32: "00000000",
+			MD5:   "d41d8cd98f00b204e9800998ecf8427e",
+			SHA1:  "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+		}},
+		{"text", "hello", Sums{
+			Name:  "text",
+			Size:  5,
+			CRC32: "3610a686",
+			MD5:   "5d41402abc4b2a76b9719d911017c592",
+			SHA1:  "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compute(tt.name, strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Compute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	plain := filepath.Join(tmpDir, "Zynaps (1987)(Hewson).d64")
+	if err := os.WriteFile(plain, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(tmpDir, "Zynaps (1987)(Hewson).zip")
+	testutils.CreateTestZip(t, archive, map[string]string{"Zynaps (1987)(Hewson).d64": "hello", "readme.txt": ""})
+
+	sums, err := File(plain)
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	if len(sums) != 1 || sums[0].Name != "Zynaps (1987)(Hewson).d64" || sums[0].CRC32 != "3610a686" {
+		t.Errorf("File(plain) = %+v", sums)
+	}
+
+	sums, err = File(archive)
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	if len(sums) != 2 {
+		t.Fatalf("File(zip) returned %d entries, want 2", len(sums))
+	}
+	for _, s := range sums {
+		if s.Name == "Zynaps (1987)(Hewson).d64" && s.SHA1 != "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d" {
+			t.Errorf("File(zip) entry %q SHA1 = %s", s.Name, s.SHA1)
+		}
+	}
+
+	if _, err := File(filepath.Join(tmpDir, "missing.d64")); err == nil {
+		t.Error("File() expected error for missing file")
+	}
+}
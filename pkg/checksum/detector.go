@@ -0,0 +1,273 @@
+package checksum
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EOF is the end offset of a rule which keeps the data up to the end of the file.
+const EOF = -1
+
+const (
+	opEqual   = "equal"
+	opLess    = "less"
+	opGreater = "greater"
+	sizePO2   = "PO2"
+)
+
+// ErrUnsupportedOperation is returned for detector rules which transform
+// the data, e.g. by swapping bytes.
+var ErrUnsupportedOperation = errors.New("unsupported header detector operation")
+
+// Detector recognizes copier headers using the rules of a clrmamepro or
+// No-Intro header detector XML file.
+type Detector struct {
+	Name    string
+	Author  string
+	Version string
+	Rules   []Rule
+}
+
+// Rule describes a header. When all of its tests pass, the data between
+// StartOffset and EndOffset is the content without the header.
+type Rule struct {
+	StartOffset int64
+	EndOffset   int64 // EOF for the end of the file
+	Tests       []Test
+}
+
+// TestKind is the type of a rule test.
+type TestKind string
+
+const (
+	// DataTest compares the bytes at Offset with Value.
+	DataTest TestKind = "data"
+	// AndTest compares the bytes at Offset masked with AND by Mask with Value.
+	AndTest TestKind = "and"
+	// OrTest compares the bytes at Offset masked with OR by Mask with Value.
+	OrTest TestKind = "or"
+	// XorTest compares the bytes at Offset masked with XOR by Mask with Value.
+	XorTest TestKind = "xor"
+	// FileTest compares the file size with Size using Operator.
+	FileTest TestKind = "file"
+)
+
+// Test is a single condition of a rule. The test passes when the outcome of
+// the condition equals Result.
+type Test struct {
+	Kind     TestKind
+	Offset   int64
+	Value    []byte
+	Mask     []byte
+	Size     int64 // Expected file size, 0 for a power of two
+	Operator string
+	Result   bool
+}
+
+type xmlDetector struct {
+	Name    string    `xml:"name"`
+	Author  string    `xml:"author"`
+	Version string    `xml:"version"`
+	Rules   []xmlRule `xml:"rule"`
+}
+
+type xmlRule struct {
+	StartOffset string    `xml:"start_offset,attr"`
+	EndOffset   string    `xml:"end_offset,attr"`
+	Operation   string    `xml:"operation,attr"`
+	Data        []xmlTest `xml:"data"`
+	And         []xmlTest `xml:"and"`
+	Or          []xmlTest `xml:"or"`
+	Xor         []xmlTest `xml:"xor"`
+	File        []xmlTest `xml:"file"`
+}
+
+type xmlTest struct {
+	Offset   string `xml:"offset,attr"`
+	Value    string `xml:"value,attr"`
+	Mask     string `xml:"mask,attr"`
+	Size     string `xml:"size,attr"`
+	Operator string `xml:"operator,attr"`
+	Result   string `xml:"result,attr"`
+}
+
+// LoadDetector reads a header detector XML file.
+func LoadDetector(path string) (*Detector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	detector, err := ParseDetector(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load '%s': %w", path, err)
+	}
+	return detector, nil
+}
+
+// ParseDetector parses a header detector XML document.
+func ParseDetector(r io.Reader) (*Detector, error) {
+	var doc xmlDetector
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid header detector: %w", err)
+	}
+
+	detector := &Detector{Name: doc.Name, Author: doc.Author, Version: doc.Version}
+	for _, xr := range doc.Rules {
+		rule, err := xr.rule()
+		if err != nil {
+			return nil, fmt.Errorf("invalid header detector rule: %w", err)
+		}
+		detector.Rules = append(detector.Rules, rule)
+	}
+	return detector, nil
+}
+
+// Detect returns the first rule matching the beginning of a file of the
+// given size. The head must hold enough bytes for the tests of all rules.
+func (d *Detector) Detect(head []byte, size int64) (Rule, bool) {
+	for _, rule := range d.Rules {
+		if rule.matches(head, size) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// headSize returns the number of bytes needed to evaluate every rule.
+func (d *Detector) headSize() int {
+	size := 0
+	for _, rule := range d.Rules {
+		size = max(size, int(rule.StartOffset))
+		for _, test := range rule.Tests {
+			size = max(size, int(test.Offset)+len(test.Value))
+		}
+	}
+	return size
+}
+
+func (rule Rule) matches(head []byte, size int64) bool {
+	for _, test := range rule.Tests {
+		if test.passes(head, size) != test.Result {
+			return false
+		}
+	}
+	return true
+}
+
+func (test Test) passes(head []byte, size int64) bool {
+	if test.Kind == FileTest {
+		return compareSize(size, test.Size, test.Operator)
+	}
+
+	end := test.Offset + int64(len(test.Value))
+	if test.Offset < 0 || end > int64(len(head)) {
+		return false
+	}
+	data := bytes.Clone(head[test.Offset:end])
+	for i := range data {
+		if i >= len(test.Mask) {
+			break
+		}
+		switch test.Kind {
+		case AndTest:
+			data[i] &= test.Mask[i]
+		case OrTest:
+			data[i] |= test.Mask[i]
+		case XorTest:
+			data[i] ^= test.Mask[i]
+		}
+	}
+	return bytes.Equal(data, test.Value)
+}
+
+func compareSize(size, expected int64, operator string) bool {
+	if expected == 0 {
+		isPowerOfTwo := size > 0 && size&(size-1) == 0
+		return isPowerOfTwo == (operator == "" || operator == opEqual)
+	}
+	switch operator {
+	case opLess:
+		return size < expected
+	case opGreater:
+		return size > expected
+	default:
+		return size == expected
+	}
+}
+
+func (xr xmlRule) rule() (Rule, error) {
+	if xr.Operation != "" && xr.Operation != "none" {
+		return Rule{}, fmt.Errorf("%w: '%s'", ErrUnsupportedOperation, xr.Operation)
+	}
+
+	rule := Rule{EndOffset: EOF}
+	var err error
+	if xr.StartOffset != "" {
+		if rule.StartOffset, err = parseHex(xr.StartOffset); err != nil {
+			return Rule{}, err
+		}
+	}
+	if xr.EndOffset != "" && !strings.EqualFold(xr.EndOffset, "EOF") {
+		if rule.EndOffset, err = parseHex(xr.EndOffset); err != nil {
+			return Rule{}, err
+		}
+	}
+
+	groups := []struct {
+		kind  TestKind
+		tests []xmlTest
+	}{
+		{DataTest, xr.Data}, {AndTest, xr.And}, {OrTest, xr.Or}, {XorTest, xr.Xor}, {FileTest, xr.File},
+	}
+	for _, group := range groups {
+		for _, xt := range group.tests {
+			test, err := xt.test(group.kind)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.Tests = append(rule.Tests, test)
+		}
+	}
+	return rule, nil
+}
+
+func (xt xmlTest) test(kind TestKind) (Test, error) {
+	test := Test{Kind: kind, Operator: xt.Operator, Result: xt.Result != "false"}
+	var err error
+	if kind == FileTest {
+		if xt.Size != sizePO2 {
+			test.Size, err = parseHex(xt.Size)
+		}
+		return test, err
+	}
+
+	if xt.Offset != "" {
+		if test.Offset, err = parseHex(xt.Offset); err != nil {
+			return Test{}, err
+		}
+	}
+	if test.Value, err = hex.DecodeString(xt.Value); err != nil {
+		return Test{}, fmt.Errorf("invalid value '%s': %w", xt.Value, err)
+	}
+	if test.Mask, err = hex.DecodeString(xt.Mask); err != nil {
+		return Test{}, fmt.Errorf("invalid mask '%s': %w", xt.Mask, err)
+	}
+	return test, nil
+}
+
+func parseHex(value string) (int64, error) {
+	n, err := strconv.ParseInt(value, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex number '%s'", value)
+	}
+	return n, nil
+}
@@ -0,0 +1,120 @@
+package checksum
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseDetector(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<detector>
+	<name>Test Header Skipper</name>
+	<author>romkit</author>
+	<version>1</version>
+	<rule start_offset="200" end_offset="EOF" operation="none">
+		<and offset="0" mask="F0" value="A0" result="true"/>
+		<file size="PO2" result="false"/>
+	</rule>
+</detector>`
+
+	detector, err := ParseDetector(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseDetector() error = %v", err)
+	}
+	if detector.Name != "Test Header Skipper" || len(detector.Rules) != 1 {
+		t.Fatalf("ParseDetector() = %+v", detector)
+	}
+	rule := detector.Rules[0]
+	if rule.StartOffset != 0x200 || rule.EndOffset != EOF || len(rule.Tests) != 2 {
+		t.Errorf("rule = %+v", rule)
+	}
+
+	tests := []struct {
+		name string
+		head []byte
+		size int64
+		want bool
+	}{
+		{"masked byte matches and size is not a power of two", []byte{0xA5}, 0x8200, true},
+		{"size is a power of two", []byte{0xA5}, 0x8000, false},
+		{"masked byte differs", []byte{0xB5}, 0x8200, false},
+		{"head too short", []byte{}, 0x8200, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := detector.Detect(tt.head, tt.size); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDetectorInvalid(t *testing.T) {
+	_, err := ParseDetector(strings.NewReader(`<detector><rule operation="byteswap"/></detector>`))
+	if !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("ParseDetector() error = %v, want ErrUnsupportedOperation", err)
+	}
+	if _, err := ParseDetector(strings.NewReader(`<detector><rule start_offset="zz"/></detector>`)); err == nil {
+		t.Error("ParseDetector() expected error for invalid offset")
+	}
+}
+
+func TestHasherSkipsHeader(t *testing.T) {
+	rom := bytes.Repeat([]byte{0xEA}, 32)
+	header := append([]byte("NES\x1a"), make([]byte, 12)...)
+	headered := append(header, rom...)
+
+	want, err := Compute("rom", bytes.NewReader(rom))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	hasher := Hasher{Detector: NESDetector}
+	got, err := hasher.Compute("rom", bytes.NewReader(headered), int64(len(headered)))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	if got.Size != int64(len(headered)) {
+		t.Errorf("Size = %d, want %d", got.Size, len(headered))
+	}
+	if got.Headerless == nil {
+		t.Fatal("Headerless = nil, want checksums without the header")
+	}
+	if *got.Headerless != want {
+		t.Errorf("Headerless = %+v, want %+v", *got.Headerless, want)
+	}
+
+	plain, err := hasher.Compute("rom", bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	if plain.Headerless != nil || plain != want {
+		t.Errorf("Compute() of headerless rom = %+v, want %+v", plain, want)
+	}
+}
+
+func TestDefaultDetectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector *Detector
+		head     []byte
+		start    int64
+	}{
+		{"iNES", NESDetector, []byte("NES\x1a"), 0x10},
+		{"fwNES", NESDetector, []byte("FDS\x1a"), 0x10},
+		{"A78", Atari7800Detector, []byte("\x01ATARI7800"), 0x80},
+		{"LNX", LynxDetector, []byte("LYNX"), 0x40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := make([]byte, tt.detector.headSize())
+			copy(head, tt.head)
+			rule, ok := tt.detector.Detect(head, 0x10000)
+			if !ok || rule.StartOffset != tt.start {
+				t.Errorf("Detect() = %+v, %v, want start offset %#x", rule, ok, tt.start)
+			}
+		})
+	}
+}
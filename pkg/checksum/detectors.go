@@ -0,0 +1,48 @@
+package checksum
+
+import "strings"
+
+// Default header detectors for platforms whose dumps commonly carry copier
+// headers. They follow the detector files distributed with No-Intro DATs.
+var (
+	// NESDetector skips the 16 byte iNES and fwNES (FDS) headers.
+	NESDetector = mustParseDetector(`<detector>
+	<name>No-Intro NES Dat iNES Header Skipper</name>
+	<rule start_offset="10" end_offset="EOF">
+		<data offset="0" value="4E45531A" result="true"/>
+	</rule>
+	<rule start_offset="10" end_offset="EOF">
+		<data offset="0" value="4644531A" result="true"/>
+	</rule>
+</detector>`)
+
+	// Atari7800Detector skips the 128 byte A78 header.
+	Atari7800Detector = mustParseDetector(`<detector>
+	<name>No-Intro Atari 7800 Dat Header Skipper</name>
+	<rule start_offset="80" end_offset="EOF">
+		<data offset="1" value="415441524937383030" result="true"/>
+	</rule>
+	<rule start_offset="80" end_offset="EOF">
+		<data offset="64" value="41435455414C20434152542044415441205354415254532048455245" result="true"/>
+	</rule>
+</detector>`)
+
+	// LynxDetector skips the 64 byte LNX header.
+	LynxDetector = mustParseDetector(`<detector>
+	<name>No-Intro Atari Lynx Dat LNX Header Skipper</name>
+	<rule start_offset="40" end_offset="EOF">
+		<data offset="0" value="4C594E58" result="true"/>
+	</rule>
+	<rule start_offset="40" end_offset="EOF">
+		<data offset="6" value="42533933" result="true"/>
+	</rule>
+</detector>`)
+)
+
+func mustParseDetector(doc string) *Detector {
+	detector, err := ParseDetector(strings.NewReader(doc))
+	if err != nil {
+		panic(err)
+	}
+	return detector
+}
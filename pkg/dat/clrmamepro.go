@@ -0,0 +1,167 @@
+package dat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrUnexpectedEOF is returned when a ClrMamePro DAT ends inside a block.
+var ErrUnexpectedEOF = errors.New("unexpected end of ClrMamePro DAT")
+
+const (
+	blockOpen  = "("
+	blockClose = ")"
+)
+
+// cmpToken is a single token of a ClrMamePro DAT. Quoted strings are kept
+// apart from bare words, so that a quoted "(" is not mistaken for a block.
+type cmpToken struct {
+	value  string
+	quoted bool
+}
+
+func (t cmpToken) is(value string) bool {
+	return !t.quoted && t.value == value
+}
+
+// cmpBlock is a parsed "name ( key value ... )" block.
+type cmpBlock struct {
+	values map[string]string
+	blocks map[string][]cmpBlock
+}
+
+// ParseClrMamePro parses a DAT in the legacy ClrMamePro text format, e.g.
+// `clrmamepro ( name "..." ) game ( name "..." rom ( name "..." size 1 crc ... ) )`.
+func ParseClrMamePro(r io.Reader) (*Datafile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := tokenizeClrMamePro(string(bytes.TrimPrefix(data, utf8BOM)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ClrMamePro DAT: %w", err)
+	}
+
+	var datafile Datafile
+	for len(tokens) > 0 {
+		name := tokens[0]
+		if len(tokens) < 2 || !tokens[1].is(blockOpen) {
+			return nil, fmt.Errorf("invalid ClrMamePro DAT: expected block after '%s'", name.value)
+		}
+		block, rest, err := parseClrMameProBlock(tokens[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ClrMamePro DAT: %w", err)
+		}
+		tokens = rest
+
+		switch name.value {
+		case "clrmamepro":
+			datafile.Header = block.header()
+		case "game", "machine", "resource":
+			game, err := block.game()
+			if err != nil {
+				return nil, fmt.Errorf("invalid ClrMamePro DAT: %w", err)
+			}
+			datafile.Games = append(datafile.Games, game)
+		}
+	}
+
+	datafile.normalize()
+	return &datafile, nil
+}
+
+// parseClrMameProBlock parses the contents of a block up to its closing
+// parenthesis and returns the remaining tokens.
+func parseClrMameProBlock(tokens []cmpToken) (cmpBlock, []cmpToken, error) {
+	block := cmpBlock{values: make(map[string]string), blocks: make(map[string][]cmpBlock)}
+	for len(tokens) > 0 {
+		if tokens[0].is(blockClose) {
+			return block, tokens[1:], nil
+		}
+		if len(tokens) < 2 {
+			break
+		}
+		key := tokens[0].value
+		if !tokens[1].is(blockOpen) {
+			block.values[key] = tokens[1].value
+			tokens = tokens[2:]
+			continue
+		}
+		nested, rest, err := parseClrMameProBlock(tokens[2:])
+		if err != nil {
+			return block, nil, err
+		}
+		block.blocks[key] = append(block.blocks[key], nested)
+		tokens = rest
+	}
+	return block, nil, ErrUnexpectedEOF
+}
+
+func tokenizeClrMamePro(data string) ([]cmpToken, error) {
+	var tokens []cmpToken
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, cmpToken{value: string(c)})
+			i++
+		case c == '"':
+			end := strings.IndexByte(data[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated quoted string")
+			}
+			tokens = append(tokens, cmpToken{value: data[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			end := strings.IndexAny(data[i:], " \t\r\n()")
+			if end < 0 {
+				end = len(data) - i
+			}
+			tokens = append(tokens, cmpToken{value: data[i : i+end]})
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+func (block cmpBlock) header() Header {
+	return Header{
+		Name:        block.values["name"],
+		Description: block.values["description"],
+		Category:    block.values["category"],
+		Version:     block.values["version"],
+		Date:        block.values["date"],
+		Author:      block.values["author"],
+		Email:       block.values["email"],
+		Homepage:    block.values["homepage"],
+		URL:         block.values["url"],
+		Comment:     block.values["comment"],
+	}
+}
+
+func (block cmpBlock) game() (Game, error) {
+	game := Game{Name: block.values["name"], Description: block.values["description"]}
+	for _, romBlock := range block.blocks["rom"] {
+		rom := Rom{
+			Name:   romBlock.values["name"],
+			CRC:    romBlock.values["crc"],
+			MD5:    romBlock.values["md5"],
+			SHA1:   romBlock.values["sha1"],
+			Status: romBlock.values["flags"],
+		}
+		if size, ok := romBlock.values["size"]; ok {
+			var err error
+			if rom.Size, err = strconv.ParseInt(size, 10, 64); err != nil {
+				return Game{}, fmt.Errorf("invalid size '%s' of rom '%s'", size, rom.Name)
+			}
+		}
+		game.Roms = append(game.Roms, rom)
+	}
+	return game, nil
+}
@@ -0,0 +1,96 @@
+package dat
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testClrMameProDat = `clrmamepro (
+	name "Commodore C64 - Games - [D64]"
+	description "Commodore C64 - Games - [D64] (TOSEC-v2023-07-10)"
+	category "TOSEC"
+	version 2023-07-10
+	author "Cassiel, Crashdisk"
+)
+
+game (
+	name "Zynaps (1987)(Hewson)"
+	description "Zynaps (1987)(Hewson)"
+	rom ( name "Zynaps (1987)(Hewson).d64" size 174848 crc 1A2B3C4D md5 D41D8CD98F00B204E9800998ECF8427E sha1 DA39A3EE5E6B4B0D3255BFEF95601890AFD80709 )
+)
+
+game (
+	name "Last Ninja, The (1987)(System 3)"
+	description "Last Ninja, The (1987)(System 3)"
+	rom ( name "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64" size 174848 crc 00000001 )
+	rom ( name "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64" size 174848 crc 00000002 )
+)
+`
+
+func TestParseClrMameProMatchesXML(t *testing.T) {
+	fromCMP, err := ParseClrMamePro(strings.NewReader(testClrMameProDat))
+	if err != nil {
+		t.Fatalf("ParseClrMamePro() error = %v", err)
+	}
+	fromXML, err := ParseXML(strings.NewReader(testLogiqxDat))
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(fromCMP.Games, fromXML.Games) {
+		t.Errorf("ParseClrMamePro() games = %+v, want %+v", fromCMP.Games, fromXML.Games)
+	}
+	if fromCMP.Header.Name != fromXML.Header.Name || fromCMP.Header.Version != fromXML.Header.Version {
+		t.Errorf("ParseClrMamePro() header = %+v, want %+v", fromCMP.Header, fromXML.Header)
+	}
+}
+
+func TestParseClrMameProInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"unclosed block", `game ( name "a" rom ( name "a.d64" )`, ErrUnexpectedEOF},
+		{"unterminated string", `game ( name "a )`, nil},
+		{"missing block", `game name "a"`, nil},
+		{"invalid size", `game ( name "a" rom ( name "a.d64" size big ) )`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseClrMamePro(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatalf("ParseClrMamePro(%q) expected error", tt.input)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseClrMamePro(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDetectsFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"xml", testLogiqxDat},
+		{"xml with BOM and blank lines", "\xef\xbb\xbf\n\n" + testLogiqxDat},
+		{"clrmamepro", testClrMameProDat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			datafile, err := Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := datafile.RomCount(); got != 3 {
+				t.Errorf("RomCount() = %d, want 3", got)
+			}
+		})
+	}
+}
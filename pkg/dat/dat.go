@@ -0,0 +1,105 @@
+// Package dat provides reading and writing of ROM DAT files describing the expected contents of a set.
+package dat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Datafile is the in-memory model of a DAT file.
+type Datafile struct {
+	XMLName xml.Name `xml:"datafile"`
+	Header  Header   `xml:"header"`
+	Games   []Game   `xml:"game"`
+}
+
+// Header holds the descriptive information of a DAT file.
+type Header struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Category    string `xml:"category,omitempty"`
+	Version     string `xml:"version"`
+	Date        string `xml:"date,omitempty"`
+	Author      string `xml:"author"`
+	Email       string `xml:"email,omitempty"`
+	Homepage    string `xml:"homepage,omitempty"`
+	URL         string `xml:"url,omitempty"`
+	Comment     string `xml:"comment,omitempty"`
+}
+
+// Game is a single set entry of a DAT file, e.g. one TOSEC title.
+type Game struct {
+	Name        string `xml:"name,attr"`
+	Description string `xml:"description"`
+	Roms        []Rom  `xml:"rom"`
+}
+
+// Rom describes a single file of a game. Hashes are stored as lowercase
+// hex strings and may be empty when the DAT does not provide them.
+type Rom struct {
+	Name   string `xml:"name,attr"`
+	Size   int64  `xml:"size,attr"`
+	CRC    string `xml:"crc,attr,omitempty"`
+	MD5    string `xml:"md5,attr,omitempty"`
+	SHA1   string `xml:"sha1,attr,omitempty"`
+	Status string `xml:"status,attr,omitempty"`
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Load reads a DAT file from the given path, detecting its format.
+func Load(path string) (*Datafile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	datafile, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load '%s': %w", path, err)
+	}
+	return datafile, nil
+}
+
+// Parse reads a DAT in either the Logiqx XML or the ClrMamePro format.
+// The format is detected from the first non-blank character.
+func Parse(r io.Reader) (*Datafile, error) {
+	br := bufio.NewReader(r)
+	if isXML(br) {
+		return ParseXML(br)
+	}
+	return ParseClrMamePro(br)
+}
+
+func isXML(br *bufio.Reader) bool {
+	// A short read only means the DAT is smaller than the peeked size
+	peek, _ := br.Peek(512)
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(peek, utf8BOM), " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '<'
+}
+
+// RomCount returns the number of roms in all games of the DAT.
+func (d *Datafile) RomCount() int {
+	count := 0
+	for _, game := range d.Games {
+		count += len(game.Roms)
+	}
+	return count
+}
+
+func (d *Datafile) normalize() {
+	for i := range d.Games {
+		for j := range d.Games[i].Roms {
+			rom := &d.Games[i].Roms[j]
+			rom.CRC = strings.ToLower(rom.CRC)
+			rom.MD5 = strings.ToLower(rom.MD5)
+			rom.SHA1 = strings.ToLower(rom.SHA1)
+		}
+	}
+}
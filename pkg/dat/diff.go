@@ -0,0 +1,154 @@
+package dat
+
+import "github.com/climbus/retro-romkit/pkg/checksum"
+
+// RomChange pairs a rom of an old DAT with its counterpart in a new DAT.
+type RomChange struct {
+	Old Rom
+	New Rom
+}
+
+// GameChange pairs a game of an old DAT with its counterpart in a new DAT.
+type GameChange struct {
+	Old Game
+	New Game
+}
+
+// Diff lists the differences between two releases of a DAT. Roms are
+// identified by name; a removed rom whose checksums match an added rom is
+// reported as renamed instead.
+type Diff struct {
+	Added   []Rom
+	Removed []Rom
+	Renamed []RomChange
+	Changed []RomChange // Roms kept under the same name with different checksums
+	// RenamedGames lists the games no longer in the new DAT whose roms all
+	// moved to a single game of another name, new to the DAT.
+	RenamedGames []GameChange
+}
+
+// Compare returns the differences from the old to the new DAT.
+func Compare(oldDat, newDat *Datafile) Diff {
+	oldRoms := romsByName(oldDat)
+	newRoms := romsByName(newDat)
+
+	added := &Datafile{Games: []Game{{}}}
+	for _, game := range newDat.Games {
+		for _, rom := range game.Roms {
+			if _, ok := oldRoms[rom.Name]; !ok {
+				added.Games[0].Roms = append(added.Games[0].Roms, rom)
+			}
+		}
+	}
+	idx := NewIndex(added)
+	renamedTo := make(map[string]bool)
+	newNames := make(map[string]string)
+
+	var diff Diff
+	for _, game := range oldDat.Games {
+		for _, rom := range game.Roms {
+			newRom, ok := newRoms[rom.Name]
+			if ok {
+				if !sameChecksums(rom, newRom) {
+					diff.Changed = append(diff.Changed, RomChange{Old: rom, New: newRom})
+				}
+				continue
+			}
+			if newRom, ok := renameTarget(idx.Lookup(rom.Sums()), renamedTo); ok {
+				renamedTo[newRom.Name] = true
+				newNames[rom.Name] = newRom.Name
+				diff.Renamed = append(diff.Renamed, RomChange{Old: rom, New: newRom})
+				continue
+			}
+			diff.Removed = append(diff.Removed, rom)
+		}
+	}
+	for _, rom := range added.Games[0].Roms {
+		if !renamedTo[rom.Name] {
+			diff.Added = append(diff.Added, rom)
+		}
+	}
+	diff.RenamedGames = renamedGames(oldDat, newDat, newNames)
+	return diff
+}
+
+// renamedGames returns the games of the old DAT which are replaced by a
+// single game of the new DAT, following the renamed roms by newNames.
+func renamedGames(oldDat, newDat *Datafile, newNames map[string]string) []GameChange {
+	oldGames := make(map[string]bool)
+	for _, game := range oldDat.Games {
+		oldGames[game.Name] = true
+	}
+	newGames := make(map[string]*Game)
+	gameOfRom := make(map[string]*Game)
+	for i := range newDat.Games {
+		game := &newDat.Games[i]
+		newGames[game.Name] = game
+		for _, rom := range game.Roms {
+			gameOfRom[rom.Name] = game
+		}
+	}
+
+	var changes []GameChange
+	claimed := make(map[string]bool)
+	for _, game := range oldDat.Games {
+		if newGames[game.Name] != nil {
+			continue
+		}
+		target := gameTarget(game, gameOfRom, newNames)
+		if target == nil || oldGames[target.Name] || claimed[target.Name] {
+			continue
+		}
+		claimed[target.Name] = true
+		changes = append(changes, GameChange{Old: game, New: *target})
+	}
+	return changes
+}
+
+// gameTarget returns the new game holding every rom of the game, or nil
+// when its roms are missing or spread over several games.
+func gameTarget(game Game, gameOfRom map[string]*Game, newNames map[string]string) *Game {
+	var target *Game
+	for _, rom := range game.Roms {
+		name := rom.Name
+		if newName, ok := newNames[name]; ok {
+			name = newName
+		}
+		g := gameOfRom[name]
+		if g == nil || (target != nil && g != target) {
+			return nil
+		}
+		target = g
+	}
+	return target
+}
+
+// Sums returns the size and checksums of the rom.
+func (r Rom) Sums() checksum.Sums {
+	return checksum.Sums{Name: r.Name, Size: r.Size, CRC32: r.CRC, MD5: r.MD5, SHA1: r.SHA1}
+}
+
+// renameTarget returns the first matching rom which no other rom was
+// renamed to.
+func renameTarget(matches []Match, renamedTo map[string]bool) (Rom, bool) {
+	for _, match := range matches {
+		if !renamedTo[match.Rom.Name] {
+			return *match.Rom, true
+		}
+	}
+	return Rom{}, false
+}
+
+func romsByName(datafile *Datafile) map[string]Rom {
+	roms := make(map[string]Rom)
+	for _, game := range datafile.Games {
+		for _, rom := range game.Roms {
+			roms[rom.Name] = rom
+		}
+	}
+	return roms
+}
+
+func sameChecksums(a, b Rom) bool {
+	return a.Size == b.Size && a.CRC == b.CRC && a.MD5 == b.MD5 && a.SHA1 == b.SHA1
+}
@@ -0,0 +1,56 @@
+package dat
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	oldDat := &Datafile{Games: []Game{
+		{Name: "Zynaps (1987)(Hewson)", Roms: []Rom{{Name: "Zynaps (1987)(Hewson).d64", Size: 5, CRC: "00000001"}}},
+		{Name: "Uridium (1986)(Hewson)", Roms: []Rom{{Name: "Uridium (1986)(Hewson).d64", Size: 5, CRC: "00000002"}}},
+		{Name: "Exolon (1987)(Hewson)", Roms: []Rom{{Name: "Exolon (1987)(Hewson).d64", Size: 5, CRC: "00000003"}}},
+		{Name: "Paradroid (1985)(Hewson)", Roms: []Rom{{Name: "Paradroid (1985)(Hewson).d64", Size: 5, CRC: "00000004"}}},
+	}}
+	newDat := &Datafile{Games: []Game{
+		{Name: "Zynaps (1987)(Hewson)", Roms: []Rom{{Name: "Zynaps (1987)(Hewson).d64", Size: 5, CRC: "00000001"}}},
+		{Name: "Uridium (1986)(Hewson Consultants)", Roms: []Rom{{Name: "Uridium (1986)(Hewson Consultants).d64", Size: 5, CRC: "00000002"}}},
+		{Name: "Exolon (1987)(Hewson)", Roms: []Rom{{Name: "Exolon (1987)(Hewson).d64", Size: 5, CRC: "00000033"}}},
+		{Name: "Nebulus (1987)(Hewson)", Roms: []Rom{{Name: "Nebulus (1987)(Hewson).d64", Size: 5, CRC: "00000005"}}},
+	}}
+
+	diff := Compare(oldDat, newDat)
+
+	if len(diff.Added) != 1 || diff.Added[0].Name != "Nebulus (1987)(Hewson).d64" {
+		t.Errorf("Added = %+v, want Nebulus", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "Paradroid (1985)(Hewson).d64" {
+		t.Errorf("Removed = %+v, want Paradroid", diff.Removed)
+	}
+	if len(diff.Renamed) != 1 || diff.Renamed[0].Old.Name != "Uridium (1986)(Hewson).d64" || diff.Renamed[0].New.Name != "Uridium (1986)(Hewson Consultants).d64" {
+		t.Errorf("Renamed = %+v, want Uridium", diff.Renamed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].New.CRC != "00000033" {
+		t.Errorf("Changed = %+v, want Exolon", diff.Changed)
+	}
+	if len(diff.RenamedGames) != 1 || diff.RenamedGames[0].Old.Name != "Uridium (1986)(Hewson)" || diff.RenamedGames[0].New.Name != "Uridium (1986)(Hewson Consultants)" {
+		t.Errorf("RenamedGames = %+v, want Uridium", diff.RenamedGames)
+	}
+}
+
+func TestCompareRenamedGames(t *testing.T) {
+	oldDat := &Datafile{Games: []Game{
+		{Name: "Game A", Roms: []Rom{{Name: "a.bin", Size: 1, CRC: "00000001"}, {Name: "b.bin", Size: 1, CRC: "00000002"}}},
+		{Name: "Game B", Roms: []Rom{{Name: "c.bin", Size: 1, CRC: "00000003"}, {Name: "d.bin", Size: 1, CRC: "00000004"}}},
+	}}
+	newDat := &Datafile{Games: []Game{
+		{Name: "Game A (v2)", Roms: []Rom{{Name: "a.bin", Size: 1, CRC: "00000001"}, {Name: "b2.bin", Size: 1, CRC: "00000002"}}},
+		{Name: "Game C", Roms: []Rom{{Name: "c.bin", Size: 1, CRC: "00000003"}}},
+		{Name: "Game D", Roms: []Rom{{Name: "d.bin", Size: 1, CRC: "00000004"}}},
+	}}
+
+	diff := Compare(oldDat, newDat)
+
+	if len(diff.RenamedGames) != 1 || diff.RenamedGames[0].Old.Name != "Game A" || diff.RenamedGames[0].New.Name != "Game A (v2)" {
+		t.Errorf("RenamedGames = %+v, want only Game A", diff.RenamedGames)
+	}
+}
@@ -0,0 +1,78 @@
+package dat
+
+import "github.com/climbus/retro-romkit/pkg/checksum"
+
+// Match is a rom found in a DAT together with the game it belongs to.
+type Match struct {
+	Game *Game
+	Rom  *Rom
+}
+
+type crcKey struct {
+	crc  string
+	size int64
+}
+
+// Index looks up the roms of a DAT by their checksums. DATs may list the
+// same contents under several games, so every checksum maps to all roms
+// carrying it.
+type Index struct {
+	bySHA1 map[string][]Match
+	byMD5  map[string][]Match
+	byCRC  map[crcKey][]Match
+}
+
+// NewIndex indexes the roms of the DAT. Roms marked as "nodump" are skipped,
+// as their checksums are unknown.
+func NewIndex(d *Datafile) *Index {
+	idx := &Index{
+		bySHA1: make(map[string][]Match),
+		byMD5:  make(map[string][]Match),
+		byCRC:  make(map[crcKey][]Match),
+	}
+	for i := range d.Games {
+		game := &d.Games[i]
+		for j := range game.Roms {
+			rom := &game.Roms[j]
+			if rom.Status == "nodump" {
+				continue
+			}
+			match := Match{Game: game, Rom: rom}
+			if rom.SHA1 != "" {
+				idx.bySHA1[rom.SHA1] = append(idx.bySHA1[rom.SHA1], match)
+			}
+			if rom.MD5 != "" {
+				idx.byMD5[rom.MD5] = append(idx.byMD5[rom.MD5], match)
+			}
+			if rom.CRC != "" {
+				key := crcKey{crc: rom.CRC, size: rom.Size}
+				idx.byCRC[key] = append(idx.byCRC[key], match)
+			}
+		}
+	}
+	return idx
+}
+
+// Lookup returns the roms matching the checksums, in DAT order, trying
+// SHA1, then MD5 and finally CRC32 together with the size. When the file
+// carries a copier header, the checksums without the header are tried as
+// well. It returns nil when no rom matches.
+func (idx *Index) Lookup(sums checksum.Sums) []Match {
+	if matches := idx.lookup(sums); matches != nil {
+		return matches
+	}
+	if sums.Headerless != nil {
+		return idx.lookup(*sums.Headerless)
+	}
+	return nil
+}
+
+func (idx *Index) lookup(sums checksum.Sums) []Match {
+	if matches, ok := idx.bySHA1[sums.SHA1]; ok && sums.SHA1 != "" {
+		return matches
+	}
+	if matches, ok := idx.byMD5[sums.MD5]; ok && sums.MD5 != "" {
+		return matches
+	}
+	return idx.byCRC[crcKey{crc: sums.CRC32, size: sums.Size}]
+}
@@ -0,0 +1,64 @@
+package dat
+
+import (
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/checksum"
+)
+
+func TestIndexLookup(t *testing.T) {
+	datafile := &Datafile{
+		Games: []Game{
+			{Name: "Full", Roms: []Rom{{Name: "full.d64", Size: 5, CRC: "3610a686", MD5: "5d41402abc4b2a76b9719d911017c592", SHA1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}}},
+			{Name: "CRC only", Roms: []Rom{{Name: "crc.d64", Size: 10, CRC: "0000000a"}}},
+			{Name: "No dump", Roms: []Rom{{Name: "nodump.d64", Size: 0, CRC: "00000000", Status: "nodump"}}},
+		},
+	}
+	idx := NewIndex(datafile)
+
+	tests := []struct {
+		name     string
+		sums     checksum.Sums
+		wantRom  string
+		wantFind bool
+	}{
+		{"sha1 match", checksum.Sums{SHA1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}, "full.d64", true},
+		{"md5 match", checksum.Sums{MD5: "5d41402abc4b2a76b9719d911017c592"}, "full.d64", true},
+		{"crc and size match", checksum.Sums{CRC32: "0000000a", Size: 10, SHA1: "ffff"}, "crc.d64", true},
+		{"crc match with other size", checksum.Sums{CRC32: "0000000a", Size: 11}, "", false},
+		{"nodump is skipped", checksum.Sums{CRC32: "00000000", Size: 0}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := idx.Lookup(tt.sums)
+			if ok := len(matches) > 0; ok != tt.wantFind {
+				t.Fatalf("Lookup() found = %v, want %v", ok, tt.wantFind)
+			}
+			if len(matches) > 0 && matches[0].Rom.Name != tt.wantRom {
+				t.Errorf("Lookup() rom = %q, want %q", matches[0].Rom.Name, tt.wantRom)
+			}
+		})
+	}
+}
+
+func TestIndexLookupHeaderless(t *testing.T) {
+	idx := NewIndex(&Datafile{Games: []Game{{Name: "Game", Roms: []Rom{{Name: "game.nes", Size: 32, CRC: "0000000b"}}}}})
+
+	sums := checksum.Sums{Size: 48, CRC32: "0000000a", Headerless: &checksum.Sums{Size: 32, CRC32: "0000000b"}}
+	if matches := idx.Lookup(sums); len(matches) != 1 || matches[0].Rom.Name != "game.nes" {
+		t.Errorf("Lookup() = %+v, want game.nes by headerless checksums", matches)
+	}
+}
+
+func TestIndexLookupSharedContents(t *testing.T) {
+	idx := NewIndex(&Datafile{Games: []Game{
+		{Name: "Game", Roms: []Rom{{Name: "game.d64", Size: 5, CRC: "3610a686"}}},
+		{Name: "Compilation", Roms: []Rom{{Name: "compilation.d64", Size: 5, CRC: "3610a686"}}},
+	}})
+
+	matches := idx.Lookup(checksum.Sums{Size: 5, CRC32: "3610a686"})
+	if len(matches) != 2 || matches[0].Rom.Name != "game.d64" || matches[1].Rom.Name != "compilation.d64" {
+		t.Errorf("Lookup() = %+v, want both roms in DAT order", matches)
+	}
+}
@@ -0,0 +1,33 @@
+package dat
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const logiqxDoctype = `<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/Dats/datafile.dtd">`
+
+// ParseXML parses a DAT in the Logiqx XML format used by TOSEC and No-Intro.
+func ParseXML(r io.Reader) (*Datafile, error) {
+	var datafile Datafile
+	if err := xml.NewDecoder(r).Decode(&datafile); err != nil {
+		return nil, fmt.Errorf("invalid Logiqx XML DAT: %w", err)
+	}
+	datafile.normalize()
+	return &datafile, nil
+}
+
+// WriteXML writes the DAT in the Logiqx XML format.
+func WriteXML(w io.Writer, datafile *Datafile) error {
+	if _, err := io.WriteString(w, xml.Header+logiqxDoctype+"\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(datafile); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
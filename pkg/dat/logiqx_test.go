@@ -0,0 +1,106 @@
+package dat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testLogiqxDat = `<?xml version="1.0"?>
+<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/Dats/datafile.dtd">
+<datafile>
+	<header>
+		<name>Commodore C64 - Games - [D64]</name>
+		<description>Commodore C64 - Games - [D64] (TOSEC-v2023-07-10)</description>
+		<category>TOSEC</category>
+		<version>2023-07-10</version>
+		<author>Cassiel, Crashdisk</author>
+		<email>contact@tosecdev.org</email>
+		<homepage>TOSEC</homepage>
+		<url>http://www.tosecdev.org/</url>
+	</header>
+	<game name="Zynaps (1987)(Hewson)">
+		<description>Zynaps (1987)(Hewson)</description>
+		<rom name="Zynaps (1987)(Hewson).d64" size="174848" crc="1A2B3C4D" md5="D41D8CD98F00B204E9800998ECF8427E" sha1="DA39A3EE5E6B4B0D3255BFEF95601890AFD80709"/>
+	</game>
+	<game name="Last Ninja, The (1987)(System 3)">
+		<description>Last Ninja, The (1987)(System 3)</description>
+		<rom name="Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64" size="174848" crc="00000001"/>
+		<rom name="Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64" size="174848" crc="00000002"/>
+	</game>
+</datafile>`
+
+func TestParseXML(t *testing.T) {
+	datafile, err := ParseXML(strings.NewReader(testLogiqxDat))
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+
+	if datafile.Header.Name != "Commodore C64 - Games - [D64]" {
+		t.Errorf("Header.Name = %q, want %q", datafile.Header.Name, "Commodore C64 - Games - [D64]")
+	}
+	if datafile.Header.Version != "2023-07-10" {
+		t.Errorf("Header.Version = %q, want %q", datafile.Header.Version, "2023-07-10")
+	}
+	if len(datafile.Games) != 2 {
+		t.Fatalf("len(Games) = %d, want 2", len(datafile.Games))
+	}
+	if got := datafile.RomCount(); got != 3 {
+		t.Errorf("RomCount() = %d, want 3", got)
+	}
+
+	want := Rom{
+		Name: "Zynaps (1987)(Hewson).d64",
+		Size: 174848,
+		CRC:  "1a2b3c4d",
+		MD5:  "d41d8cd98f00b204e9800998ecf8427e",
+		SHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+	}
+	if got := datafile.Games[0].Roms[0]; got != want {
+		t.Errorf("Roms[0] = %+v, want %+v", got, want)
+	}
+	if got := datafile.Games[1].Roms[1].Name; got != "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64" {
+		t.Errorf("Games[1].Roms[1].Name = %q", got)
+	}
+}
+
+func TestParseXMLInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not xml", "clrmamepro ( name \"test\" )"},
+		{"invalid size", `<datafile><game name="a"><rom name="a.d64" size="big"/></game></datafile>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseXML(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("ParseXML(%q) expected error", tt.input)
+			}
+		})
+	}
+}
+
+func TestWriteXMLRoundTrip(t *testing.T) {
+	datafile, err := ParseXML(strings.NewReader(testLogiqxDat))
+	if err != nil {
+		t.Fatalf("ParseXML() error = %v", err)
+	}
+
+	var sb strings.Builder
+	if err := WriteXML(&sb, datafile); err != nil {
+		t.Fatalf("WriteXML() error = %v", err)
+	}
+	if !strings.HasPrefix(sb.String(), "<?xml") || !strings.Contains(sb.String(), "<!DOCTYPE datafile") {
+		t.Errorf("WriteXML() output is missing the XML declaration or doctype:\n%s", sb.String())
+	}
+
+	written, err := ParseXML(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("ParseXML() of written DAT error = %v", err)
+	}
+	if !reflect.DeepEqual(written, datafile) {
+		t.Errorf("round trip = %+v, want %+v", written, datafile)
+	}
+}
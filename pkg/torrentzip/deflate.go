@@ -0,0 +1,819 @@
+package torrentzip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// The deflater below follows the level 9 compressor of zlib step by step,
+// as TorrentZip archives are only identical across tools when every tool
+// produces the exact deflate stream of zlib's deflate_slow with a 32 KiB
+// window, memory level 8 and the default strategy.
+
+const (
+	windowSize   = 1 << 15
+	windowMask   = windowSize - 1
+	hashBits     = 15 // Memory level 8 + 7
+	hashSize     = 1 << hashBits
+	hashMask     = hashSize - 1
+	hashShift    = (hashBits + minMatch - 1) / minMatch
+	litBufSize   = 1 << 14 // Memory level 8 + 6
+	minMatch     = 3
+	maxMatch     = 258
+	minLookahead = maxMatch + minMatch + 1
+	maxDist      = windowSize - minLookahead
+	tooFar       = 4096
+
+	// Compression level 9 parameters
+	goodMatch    = 32
+	maxLazyMatch = 258
+	niceMatch    = 258
+	maxChain     = 4096
+
+	literals    = 256
+	lengthCodes = 29
+	lCodes      = literals + 1 + lengthCodes
+	dCodes      = 30
+	blCodes     = 19
+	heapSize    = 2*lCodes + 1
+	maxBits     = 15
+	maxBLBits   = 7
+	endBlock    = 256
+	rep3To6     = 16
+	repZ3To10   = 17
+	repZ11To138 = 18
+
+	storedBlock = 0
+	staticTrees = 1
+	dynTrees    = 2
+)
+
+var (
+	extraLBits  = []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	extraDBits  = []int{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	extraBLBits = []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 3, 7}
+	blOrder     = []int{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+
+	staticLTree [lCodes + 2]treeNode
+	staticDTree [dCodes]treeNode
+	distCode    [512]uint8
+	lengthCode  [maxMatch - minMatch + 1]uint8
+	baseLength  [lengthCodes]int
+	baseDist    [dCodes]int
+
+	staticLDesc  = staticTreeDesc{tree: staticLTree[:], extraBits: extraLBits, extraBase: literals + 1, elems: lCodes, maxLength: maxBits}
+	staticDDesc  = staticTreeDesc{tree: staticDTree[:], extraBits: extraDBits, elems: dCodes, maxLength: maxBits}
+	staticBLDesc = staticTreeDesc{extraBits: extraBLBits, elems: blCodes, maxLength: maxBLBits}
+)
+
+// treeNode is a node of a Huffman tree. zlib keeps freq and code, and dad
+// and len, in unions; they are separate here.
+type treeNode struct {
+	freq, code, dad, len int
+}
+
+type staticTreeDesc struct {
+	tree      []treeNode // Static tree, nil for the bit length tree
+	extraBits []int
+	extraBase int
+	elems     int
+	maxLength int
+}
+
+type treeDesc struct {
+	tree    []treeNode
+	maxCode int
+	stat    *staticTreeDesc
+}
+
+// symbol is a literal, when dist is 0, or a match of length lc+minMatch.
+type symbol struct {
+	dist uint16
+	lc   uint8
+}
+
+// deflater holds the state of zlib's deflate_slow.
+type deflater struct {
+	r   *bufio.Reader
+	out io.Writer
+
+	window []byte
+	prev   []uint16
+	head   []uint16
+	insH   int
+
+	strStart, blockStart, lookahead, insert int
+	matchLength, matchStart, prevLength     int
+	prevMatch                               int
+	matchAvailable                          bool
+	dynLTree, dynDTree, blTree              []treeNode
+	lDesc, dDesc, blDesc                    treeDesc
+	blCount                                 [maxBits + 1]int
+	heap                                    [heapSize]int
+	heapLen, heapMax                        int
+	depth                                   [heapSize]int
+	syms                                    []symbol
+	optLen, staticLen                       int
+	bitBuf                                  uint64
+	bitCount                                int
+	pending                                 []byte
+}
+
+func init() {
+	length := 0
+	code := 0
+	for ; code < lengthCodes-1; code++ {
+		baseLength[code] = length
+		for range 1 << extraLBits[code] {
+			lengthCode[length] = uint8(code)
+			length++
+		}
+	}
+	// Length 258 has a code of its own, overwriting the last entry
+	lengthCode[length-1] = uint8(code)
+
+	dist := 0
+	for code = 0; code < 16; code++ {
+		baseDist[code] = dist
+		for range 1 << extraDBits[code] {
+			distCode[dist] = uint8(code)
+			dist++
+		}
+	}
+	dist >>= 7
+	for ; code < dCodes; code++ {
+		baseDist[code] = dist << 7
+		for range 1 << (extraDBits[code] - 7) {
+			distCode[256+dist] = uint8(code)
+			dist++
+		}
+	}
+
+	var blCount [maxBits + 1]int
+	for n := range staticLTree {
+		switch {
+		case n <= 143, n >= 280:
+			staticLTree[n].len = 8
+		case n <= 255:
+			staticLTree[n].len = 9
+		default:
+			staticLTree[n].len = 7
+		}
+		blCount[staticLTree[n].len]++
+	}
+	genCodes(staticLTree[:], lCodes+1, &blCount)
+	for n := range staticDTree {
+		staticDTree[n].len = 5
+		staticDTree[n].code = bitReverse(n, 5)
+	}
+}
+
+// deflate compresses r into a raw deflate stream written to w, identical
+// to the output of zlib at compression level 9.
+func deflate(w io.Writer, r io.Reader) error {
+	d := &deflater{
+		r:           bufio.NewReader(r),
+		out:         w,
+		window:      make([]byte, 2*windowSize),
+		prev:        make([]uint16, windowSize),
+		head:        make([]uint16, hashSize),
+		dynLTree:    make([]treeNode, heapSize),
+		dynDTree:    make([]treeNode, 2*dCodes+1),
+		blTree:      make([]treeNode, 2*blCodes+1),
+		matchLength: minMatch - 1,
+		prevLength:  minMatch - 1,
+		syms:        make([]symbol, 0, litBufSize-1),
+	}
+	d.lDesc = treeDesc{tree: d.dynLTree, stat: &staticLDesc}
+	d.dDesc = treeDesc{tree: d.dynDTree, stat: &staticDDesc}
+	d.blDesc = treeDesc{tree: d.blTree, stat: &staticBLDesc}
+	d.initBlock()
+	return d.compress()
+}
+
+// compress is deflate_slow called with Z_FINISH: matches are only chosen
+// after checking whether the next position gives a longer one.
+func (d *deflater) compress() error {
+	for {
+		if d.lookahead < minLookahead {
+			if err := d.fillWindow(); err != nil {
+				return err
+			}
+			if d.lookahead == 0 {
+				break
+			}
+		}
+
+		d.findMatch()
+
+		switch {
+		case d.prevLength >= minMatch && d.matchLength <= d.prevLength:
+			// Output the previous match, the current one is not longer
+			if d.tallyMatch() {
+				if err := d.flushBlock(d.strStart, false); err != nil {
+					return err
+				}
+			}
+		case d.matchAvailable:
+			// No better match, output the previous byte as a literal
+			if d.tallyLit(d.window[d.strStart-1]) {
+				if err := d.flushBlock(d.strStart, false); err != nil {
+					return err
+				}
+			}
+			d.strStart++
+			d.lookahead--
+		default:
+			d.matchAvailable = true
+			d.strStart++
+			d.lookahead--
+		}
+	}
+
+	if d.matchAvailable {
+		d.tallyLit(d.window[d.strStart-1])
+		d.matchAvailable = false
+	}
+	return d.flushBlock(d.strStart, true)
+}
+
+// findMatch inserts the string at the current position into the hash
+// chains and looks for a match, keeping the previous one for comparison.
+func (d *deflater) findMatch() {
+	hashHead := 0
+	if d.lookahead >= minMatch {
+		hashHead = d.insertString(d.strStart)
+	}
+	d.prevLength, d.prevMatch = d.matchLength, d.matchStart
+	d.matchLength = minMatch - 1
+	if hashHead != 0 && d.prevLength < maxLazyMatch && d.strStart-hashHead <= maxDist {
+		d.matchLength = d.longestMatch(hashHead)
+		if d.matchLength == minMatch && d.strStart-d.matchStart > tooFar {
+			d.matchLength = minMatch - 1
+		}
+	}
+}
+
+// tallyMatch records the previous match and moves past it, inserting the
+// strings it covers into the hash chains. It reports whether the block is
+// full.
+func (d *deflater) tallyMatch() bool {
+	maxInsert := d.strStart + d.lookahead - minMatch
+	flush := d.tallyDist(d.strStart-1-d.prevMatch, d.prevLength-minMatch)
+	d.lookahead -= d.prevLength - 1
+	for d.prevLength -= 2; d.prevLength > 0; d.prevLength-- {
+		d.strStart++
+		if d.strStart <= maxInsert {
+			d.insertString(d.strStart)
+		}
+	}
+	d.matchAvailable = false
+	d.matchLength = minMatch - 1
+	d.strStart++
+	return flush
+}
+
+// fillWindow reads input into the window, sliding it by windowSize when
+// the current position gets close to its end.
+func (d *deflater) fillWindow() error {
+	for {
+		more := len(d.window) - d.lookahead - d.strStart
+		if d.strStart >= windowSize+maxDist {
+			copy(d.window, d.window[windowSize:2*windowSize-more])
+			d.matchStart -= windowSize
+			d.strStart -= windowSize
+			d.blockStart -= windowSize
+			d.insert = min(d.insert, d.strStart)
+			slideHash(d.head)
+			slideHash(d.prev)
+			more += windowSize
+		}
+		if !d.hasInput() {
+			break
+		}
+
+		start := d.strStart + d.lookahead
+		n, err := io.ReadFull(d.r, d.window[start:start+more])
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		d.lookahead += n
+
+		if d.lookahead+d.insert >= minMatch {
+			str := d.strStart - d.insert
+			d.insH = int(d.window[str])
+			d.updateHash(d.window[str+1])
+			for d.insert > 0 {
+				d.updateHash(d.window[str+minMatch-1])
+				d.prev[str&windowMask] = d.head[d.insH]
+				d.head[d.insH] = uint16(str)
+				str++
+				d.insert--
+				if d.lookahead+d.insert < minMatch {
+					break
+				}
+			}
+		}
+		if d.lookahead >= minLookahead || !d.hasInput() {
+			break
+		}
+	}
+	return nil
+}
+
+// hasInput reports whether unread input is left.
+func (d *deflater) hasInput() bool {
+	_, err := d.r.Peek(1)
+	return err == nil
+}
+
+func (d *deflater) updateHash(c byte) {
+	d.insH = ((d.insH << hashShift) ^ int(c)) & hashMask
+}
+
+// insertString adds the string at pos to the hash chains and returns the
+// previous head of its chain.
+func (d *deflater) insertString(pos int) int {
+	d.updateHash(d.window[pos+minMatch-1])
+	head := d.head[d.insH]
+	d.prev[pos&windowMask] = head
+	d.head[d.insH] = uint16(pos)
+	return int(head)
+}
+
+// longestMatch follows the hash chain from curMatch and returns the length
+// of the longest match found, setting matchStart.
+func (d *deflater) longestMatch(curMatch int) int {
+	chainLength := maxChain
+	scan := d.strStart
+	bestLen := d.prevLength
+	nice := niceMatch
+	limit := 0
+	if d.strStart > maxDist {
+		limit = d.strStart - maxDist
+	}
+	strEnd := d.strStart + maxMatch
+	w := d.window
+	scanEnd1, scanEnd := w[scan+bestLen-1], w[scan+bestLen]
+
+	if d.prevLength >= goodMatch {
+		chainLength >>= 2
+	}
+	nice = min(nice, d.lookahead)
+
+	for {
+		match := curMatch
+		if w[match+bestLen] == scanEnd && w[match+bestLen-1] == scanEnd1 &&
+			w[match] == w[scan] && w[match+1] == w[scan+1] {
+			// The third bytes are equal when the hashes and the first two
+			// bytes are, so the comparison continues with the fourth
+			s, m := scan+2, match+2
+			for {
+				s++
+				m++
+				if w[s] != w[m] || s >= strEnd {
+					break
+				}
+			}
+			length := min(s-scan, maxMatch)
+			if length > bestLen {
+				d.matchStart = curMatch
+				bestLen = length
+				if length >= nice {
+					break
+				}
+				scanEnd1, scanEnd = w[scan+bestLen-1], w[scan+bestLen]
+			}
+		}
+
+		curMatch = int(d.prev[curMatch&windowMask])
+		chainLength--
+		if curMatch <= limit || chainLength == 0 {
+			break
+		}
+	}
+
+	return min(bestLen, d.lookahead)
+}
+
+func (d *deflater) tallyLit(c byte) bool {
+	d.syms = append(d.syms, symbol{lc: c})
+	d.dynLTree[c].freq++
+	return len(d.syms) == litBufSize-1
+}
+
+func (d *deflater) tallyDist(dist, length int) bool {
+	d.syms = append(d.syms, symbol{dist: uint16(dist), lc: uint8(length)})
+	d.dynLTree[int(lengthCode[length])+literals+1].freq++
+	d.dynDTree[dCode(dist-1)].freq++
+	return len(d.syms) == litBufSize-1
+}
+
+// flushBlock writes the tallied symbols as a block ending at end and
+// passes the pending output on.
+func (d *deflater) flushBlock(end int, last bool) error {
+	var buf []byte
+	if d.blockStart >= 0 {
+		buf = d.window[d.blockStart:end]
+	}
+	d.writeBlock(buf, end-d.blockStart, last)
+	d.blockStart = end
+
+	_, err := d.out.Write(d.pending)
+	d.pending = d.pending[:0]
+	return err
+}
+
+// writeBlock is zlib's _tr_flush_block: the block is stored, compressed
+// with the static trees or with its own trees, whichever is shortest.
+func (d *deflater) writeBlock(buf []byte, storedLen int, last bool) {
+	d.buildTree(&d.lDesc)
+	d.buildTree(&d.dDesc)
+	maxBLIndex := d.buildBLTree()
+
+	optLenb := (d.optLen + 3 + 7) >> 3
+	staticLenb := (d.staticLen + 3 + 7) >> 3
+	if staticLenb <= optLenb {
+		optLenb = staticLenb
+	}
+
+	switch {
+	case storedLen+4 <= optLenb && buf != nil:
+		d.sendBits(storedBlock<<1+boolInt(last), 3)
+		d.alignBits()
+		d.pending = append(d.pending, byte(storedLen), byte(storedLen>>8), ^byte(storedLen), ^byte(storedLen>>8))
+		d.pending = append(d.pending, buf...)
+	case staticLenb == optLenb:
+		d.sendBits(staticTrees<<1+boolInt(last), 3)
+		d.compressBlock(staticLTree[:], staticDTree[:])
+	default:
+		d.sendBits(dynTrees<<1+boolInt(last), 3)
+		d.sendAllTrees(d.lDesc.maxCode+1, d.dDesc.maxCode+1, maxBLIndex+1)
+		d.compressBlock(d.dynLTree, d.dynDTree)
+	}
+
+	d.initBlock()
+	if last {
+		d.alignBits()
+	}
+}
+
+func (d *deflater) initBlock() {
+	for n := range lCodes {
+		d.dynLTree[n].freq = 0
+	}
+	for n := range dCodes {
+		d.dynDTree[n].freq = 0
+	}
+	for n := range blCodes {
+		d.blTree[n].freq = 0
+	}
+	d.dynLTree[endBlock].freq = 1
+	d.optLen, d.staticLen = 0, 0
+	d.syms = d.syms[:0]
+}
+
+// buildTree builds the Huffman tree of desc from the symbol frequencies
+// and sets the code lengths and codes, adding the block length to optLen
+// and staticLen.
+func (d *deflater) buildTree(desc *treeDesc) {
+	tree := desc.tree
+	stree := desc.stat.tree
+	maxCode := -1
+
+	d.heapLen, d.heapMax = 0, heapSize
+	for n := range desc.stat.elems {
+		if tree[n].freq != 0 {
+			d.heapLen++
+			d.heap[d.heapLen] = n
+			maxCode = n
+			d.depth[n] = 0
+		} else {
+			tree[n].len = 0
+		}
+	}
+
+	// At least two codes of non zero frequency are needed
+	for d.heapLen < 2 {
+		node := 0
+		if maxCode < 2 {
+			maxCode++
+			node = maxCode
+		}
+		d.heapLen++
+		d.heap[d.heapLen] = node
+		tree[node].freq = 1
+		d.depth[node] = 0
+		d.optLen--
+		if stree != nil {
+			d.staticLen -= stree[node].len
+		}
+	}
+	desc.maxCode = maxCode
+
+	for n := d.heapLen / 2; n >= 1; n-- {
+		d.downHeap(tree, n)
+	}
+
+	node := desc.stat.elems
+	for {
+		n := d.heap[1]
+		d.heap[1] = d.heap[d.heapLen]
+		d.heapLen--
+		d.downHeap(tree, 1)
+		m := d.heap[1]
+
+		d.heapMax--
+		d.heap[d.heapMax] = n
+		d.heapMax--
+		d.heap[d.heapMax] = m
+
+		tree[node].freq = tree[n].freq + tree[m].freq
+		d.depth[node] = max(d.depth[n], d.depth[m]) + 1
+		tree[n].dad, tree[m].dad = node, node
+		d.heap[1] = node
+		node++
+		d.downHeap(tree, 1)
+		if d.heapLen < 2 {
+			break
+		}
+	}
+	d.heapMax--
+	d.heap[d.heapMax] = d.heap[1]
+
+	d.genBitLen(desc)
+	genCodes(tree, maxCode, &d.blCount)
+}
+
+func (d *deflater) smaller(tree []treeNode, n, m int) bool {
+	return tree[n].freq < tree[m].freq || (tree[n].freq == tree[m].freq && d.depth[n] <= d.depth[m])
+}
+
+func (d *deflater) downHeap(tree []treeNode, k int) {
+	v := d.heap[k]
+	for j := k << 1; j <= d.heapLen; j <<= 1 {
+		if j < d.heapLen && d.smaller(tree, d.heap[j+1], d.heap[j]) {
+			j++
+		}
+		if d.smaller(tree, v, d.heap[j]) {
+			break
+		}
+		d.heap[k] = d.heap[j]
+		k = j
+	}
+	d.heap[k] = v
+}
+
+// genBitLen computes the code lengths from the tree, limiting them to the
+// maximum length of the tree.
+func (d *deflater) genBitLen(desc *treeDesc) {
+	tree := desc.tree
+	stat := desc.stat
+	overflow := 0
+	for bits := range d.blCount {
+		d.blCount[bits] = 0
+	}
+
+	tree[d.heap[d.heapMax]].len = 0
+	h := d.heapMax + 1
+	for ; h < heapSize; h++ {
+		n := d.heap[h]
+		bits := tree[tree[n].dad].len + 1
+		if bits > stat.maxLength {
+			bits = stat.maxLength
+			overflow++
+		}
+		tree[n].len = bits
+		if n > desc.maxCode {
+			continue // Not a leaf node
+		}
+		d.blCount[bits]++
+		xbits := 0
+		if n >= stat.extraBase {
+			xbits = stat.extraBits[n-stat.extraBase]
+		}
+		f := tree[n].freq
+		d.optLen += f * (bits + xbits)
+		if stat.tree != nil {
+			d.staticLen += f * (stat.tree[n].len + xbits)
+		}
+	}
+	if overflow == 0 {
+		return
+	}
+
+	for overflow > 0 {
+		bits := stat.maxLength - 1
+		for d.blCount[bits] == 0 {
+			bits--
+		}
+		d.blCount[bits]--
+		d.blCount[bits+1] += 2
+		d.blCount[stat.maxLength]--
+		overflow -= 2
+	}
+	for bits := stat.maxLength; bits != 0; bits-- {
+		for n := d.blCount[bits]; n != 0; {
+			h--
+			m := d.heap[h]
+			if m > desc.maxCode {
+				continue
+			}
+			if tree[m].len != bits {
+				d.optLen += (bits - tree[m].len) * tree[m].freq
+				tree[m].len = bits
+			}
+			n--
+		}
+	}
+}
+
+func genCodes(tree []treeNode, maxCode int, blCount *[maxBits + 1]int) {
+	var nextCode [maxBits + 1]int
+	code := 0
+	for bits := 1; bits <= maxBits; bits++ {
+		code = (code + blCount[bits-1]) << 1
+		nextCode[bits] = code
+	}
+	for n := 0; n <= maxCode; n++ {
+		length := tree[n].len
+		if length == 0 {
+			continue
+		}
+		tree[n].code = bitReverse(nextCode[length], length)
+		nextCode[length]++
+	}
+}
+
+// buildBLTree builds the tree for the code lengths of the literal and
+// distance trees and returns the index in blOrder of the last bit length
+// code to send.
+func (d *deflater) buildBLTree() int {
+	d.scanTree(d.dynLTree, d.lDesc.maxCode)
+	d.scanTree(d.dynDTree, d.dDesc.maxCode)
+	d.buildTree(&d.blDesc)
+
+	maxBLIndex := blCodes - 1
+	for ; maxBLIndex >= 3; maxBLIndex-- {
+		if d.blTree[blOrder[maxBLIndex]].len != 0 {
+			break
+		}
+	}
+	d.optLen += 3*(maxBLIndex+1) + 5 + 5 + 4
+	return maxBLIndex
+}
+
+// scanTree counts the run length encoded code lengths of the tree in the
+// bit length tree frequencies.
+func (d *deflater) scanTree(tree []treeNode, maxCode int) {
+	tree[maxCode+1].len = 0xffff // Guard
+	d.walkTree(tree, maxCode, func(code, _, _ int) {
+		d.blTree[code].freq++
+	})
+}
+
+// sendTree sends the code lengths of the tree, run length encoded.
+func (d *deflater) sendTree(tree []treeNode, maxCode int) {
+	d.walkTree(tree, maxCode, func(code, extra, extraBits int) {
+		d.sendCode(code, d.blTree)
+		if extraBits > 0 {
+			d.sendBits(extra, extraBits)
+		}
+	})
+}
+
+// walkTree run length encodes the code lengths of the tree, calling emit
+// for every bit length code with its extra bits.
+func (d *deflater) walkTree(tree []treeNode, maxCode int, emit func(code, extra, extraBits int)) {
+	prevLen := -1
+	nextLen := tree[0].len
+	count := 0
+	maxCount, minCount := 7, 4
+	if nextLen == 0 {
+		maxCount, minCount = 138, 3
+	}
+
+	for n := 0; n <= maxCode; n++ {
+		curLen := nextLen
+		nextLen = tree[n+1].len
+		count++
+		if count < maxCount && curLen == nextLen {
+			continue
+		}
+		switch {
+		case count < minCount:
+			for ; count > 0; count-- {
+				emit(curLen, 0, 0)
+			}
+		case curLen != 0:
+			if curLen != prevLen {
+				emit(curLen, 0, 0)
+				count--
+			}
+			emit(rep3To6, count-3, 2)
+		case count <= 10:
+			emit(repZ3To10, count-3, 3)
+		default:
+			emit(repZ11To138, count-11, 7)
+		}
+		count = 0
+		prevLen = curLen
+		switch {
+		case nextLen == 0:
+			maxCount, minCount = 138, 3
+		case curLen == nextLen:
+			maxCount, minCount = 6, 3
+		default:
+			maxCount, minCount = 7, 4
+		}
+	}
+}
+
+func (d *deflater) sendAllTrees(lcodes, dcodes, blcodes int) {
+	d.sendBits(lcodes-257, 5)
+	d.sendBits(dcodes-1, 5)
+	d.sendBits(blcodes-4, 4)
+	for rank := range blcodes {
+		d.sendBits(d.blTree[blOrder[rank]].len, 3)
+	}
+	d.sendTree(d.dynLTree, lcodes-1)
+	d.sendTree(d.dynDTree, dcodes-1)
+}
+
+func (d *deflater) compressBlock(ltree, dtree []treeNode) {
+	for _, sym := range d.syms {
+		if sym.dist == 0 {
+			d.sendCode(int(sym.lc), ltree)
+			continue
+		}
+		lc := int(sym.lc)
+		code := int(lengthCode[lc])
+		d.sendCode(code+literals+1, ltree)
+		if extra := extraLBits[code]; extra != 0 {
+			d.sendBits(lc-baseLength[code], extra)
+		}
+		dist := int(sym.dist) - 1
+		code = dCode(dist)
+		d.sendCode(code, dtree)
+		if extra := extraDBits[code]; extra != 0 {
+			d.sendBits(dist-baseDist[code], extra)
+		}
+	}
+	d.sendCode(endBlock, ltree)
+}
+
+func (d *deflater) sendCode(c int, tree []treeNode) {
+	d.sendBits(tree[c].code, tree[c].len)
+}
+
+func (d *deflater) sendBits(value, length int) {
+	d.bitBuf |= uint64(value) << d.bitCount
+	d.bitCount += length
+	for d.bitCount >= 8 {
+		d.pending = append(d.pending, byte(d.bitBuf))
+		d.bitBuf >>= 8
+		d.bitCount -= 8
+	}
+}
+
+// alignBits pads the output to a byte boundary.
+func (d *deflater) alignBits() {
+	if d.bitCount > 0 {
+		d.pending = append(d.pending, byte(d.bitBuf))
+	}
+	d.bitBuf, d.bitCount = 0, 0
+}
+
+func slideHash(table []uint16) {
+	for i, m := range table {
+		if m >= windowSize {
+			table[i] = m - windowSize
+		} else {
+			table[i] = 0
+		}
+	}
+}
+
+func dCode(dist int) int {
+	if dist < 256 {
+		return int(distCode[dist])
+	}
+	return int(distCode[256+dist>>7])
+}
+
+func bitReverse(code, length int) int {
+	res := 0
+	for ; length > 0; length-- {
+		res = res<<1 | code&1
+		code >>= 1
+	}
+	return res
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
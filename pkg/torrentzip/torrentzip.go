@@ -0,0 +1,293 @@
+// Package torrentzip writes and verifies TorrentZip archives: zip archives
+// with sorted entries, fixed timestamps, a fixed compression level and a
+// comment holding the checksum of the central directory, so that the same
+// files always give the same archive.
+package torrentzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	commentPrefix = "TORRENTZIPPED-"
+	commentLength = len(commentPrefix) + 8
+
+	versionNeeded  = 20
+	flagMaxDeflate = 0x0002 // General purpose flag set by deflate level 9
+	dosTime        = 0xbc00 // 23:32:00
+	dosDate        = 0x2198 // 1996-12-24
+
+	localHeaderSize   = 30
+	centralHeaderSize = 46
+	endRecordSize     = 22
+
+	localHeaderSignature   = 0x04034b50
+	centralHeaderSignature = 0x02014b50
+	endRecordSignature     = 0x06054b50
+)
+
+var (
+	// ErrNotTorrentZip is returned by Verify for archives which are not
+	// TorrentZipped.
+	ErrNotTorrentZip = errors.New("not a TorrentZip archive")
+	// ErrTooLarge is returned for files and archives which would need Zip64
+	// extensions, which TorrentZip does not use.
+	ErrTooLarge = errors.New("too large for TorrentZip")
+	// ErrDuplicateName is returned when two sources have the same name.
+	ErrDuplicateName = errors.New("duplicate file name")
+)
+
+// Source is a file to store in a TorrentZip archive.
+type Source struct {
+	Name string                        // Slash-separated path inside the archive
+	Open func() (io.ReadCloser, error) // Opens the file contents
+}
+
+// entry is a file written to the archive.
+type entry struct {
+	name             string
+	crc              uint32
+	compressedSize   uint32
+	uncompressedSize uint32
+	offset           uint32
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Create writes a TorrentZip archive holding the sources to a new file at
+// path. An existing file is never overwritten; the file is removed again
+// when writing fails.
+func Create(path string, sources []Source) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, sources); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes a TorrentZip archive holding the sources to w, which must
+// be positioned at its start. Entries are sorted by their lowercase name.
+// Each file is compressed while it is read, so memory use does not depend
+// on the file sizes, with the deflate stream zlib produces at level 9 so
+// that the archive is identical to the one written by other TorrentZip
+// tools.
+func Write(w io.WriteSeeker, sources []Source) error {
+	sorted := slices.Clone(sources)
+	slices.SortStableFunc(sorted, func(a, b Source) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	names := make(map[string]bool, len(sorted))
+	for _, source := range sorted {
+		if names[source.Name] {
+			return fmt.Errorf("%w: '%s'", ErrDuplicateName, source.Name)
+		}
+		names[source.Name] = true
+	}
+	if len(sorted) > math.MaxUint16 {
+		return fmt.Errorf("%w: %d files", ErrTooLarge, len(sorted))
+	}
+
+	cw := &countingWriter{w: w}
+	entries := make([]entry, 0, len(sorted))
+	for _, source := range sorted {
+		e, err := writeEntry(w, cw, source)
+		if err != nil {
+			return fmt.Errorf("failed to store '%s': %w", source.Name, err)
+		}
+		entries = append(entries, e)
+	}
+
+	if cw.n > math.MaxUint32 {
+		return fmt.Errorf("%w: archive exceeds 4 GiB", ErrTooLarge)
+	}
+	var central bytes.Buffer
+	for _, e := range entries {
+		writeCentralHeader(&central, e)
+	}
+	if int64(central.Len())+cw.n > math.MaxUint32 {
+		return fmt.Errorf("%w: archive exceeds 4 GiB", ErrTooLarge)
+	}
+
+	end := make([]byte, endRecordSize)
+	binary.LittleEndian.PutUint32(end, endRecordSignature)
+	binary.LittleEndian.PutUint16(end[8:], uint16(len(entries)))
+	binary.LittleEndian.PutUint16(end[10:], uint16(len(entries)))
+	binary.LittleEndian.PutUint32(end[12:], uint32(central.Len()))
+	binary.LittleEndian.PutUint32(end[16:], uint32(cw.n))
+	binary.LittleEndian.PutUint16(end[20:], uint16(commentLength))
+	comment := fmt.Sprintf("%s%08X", commentPrefix, crc32.ChecksumIEEE(central.Bytes()))
+
+	for _, b := range [][]byte{central.Bytes(), end, []byte(comment)} {
+		if _, err := cw.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEntry writes the local header and compressed contents of a source.
+// The header is written with empty checksum and sizes, which are filled in
+// once the contents have been compressed.
+func writeEntry(w io.WriteSeeker, cw *countingWriter, source Source) (entry, error) {
+	if cw.n > math.MaxUint32 {
+		return entry{}, fmt.Errorf("%w: archive exceeds 4 GiB", ErrTooLarge)
+	}
+	e := entry{name: source.Name, offset: uint32(cw.n)}
+	if _, err := cw.Write(localHeader(e)); err != nil {
+		return entry{}, err
+	}
+
+	r, err := source.Open()
+	if err != nil {
+		return entry{}, err
+	}
+	defer r.Close()
+
+	start := cw.n
+	crc := crc32.NewIEEE()
+	src := &countingWriter{w: crc}
+	if err := deflate(cw, io.TeeReader(r, src)); err != nil {
+		return entry{}, err
+	}
+	size, compressed := src.n, cw.n-start
+	if size > math.MaxUint32 || compressed > math.MaxUint32 {
+		return entry{}, fmt.Errorf("%w: file exceeds 4 GiB", ErrTooLarge)
+	}
+	e.crc, e.uncompressedSize, e.compressedSize = crc.Sum32(), uint32(size), uint32(compressed)
+
+	if _, err := w.Seek(int64(e.offset)+14, io.SeekStart); err != nil {
+		return entry{}, err
+	}
+	if _, err := w.Write(localHeader(e)[14:26]); err != nil {
+		return entry{}, err
+	}
+	if _, err := w.Seek(cw.n, io.SeekStart); err != nil {
+		return entry{}, err
+	}
+	return e, nil
+}
+
+func localHeader(e entry) []byte {
+	b := make([]byte, localHeaderSize, localHeaderSize+len(e.name))
+	binary.LittleEndian.PutUint32(b, localHeaderSignature)
+	binary.LittleEndian.PutUint16(b[4:], versionNeeded)
+	binary.LittleEndian.PutUint16(b[6:], flagMaxDeflate)
+	binary.LittleEndian.PutUint16(b[8:], zip.Deflate)
+	binary.LittleEndian.PutUint16(b[10:], dosTime)
+	binary.LittleEndian.PutUint16(b[12:], dosDate)
+	binary.LittleEndian.PutUint32(b[14:], e.crc)
+	binary.LittleEndian.PutUint32(b[18:], e.compressedSize)
+	binary.LittleEndian.PutUint32(b[22:], e.uncompressedSize)
+	binary.LittleEndian.PutUint16(b[26:], uint16(len(e.name)))
+	return append(b, e.name...)
+}
+
+func writeCentralHeader(buf *bytes.Buffer, e entry) {
+	b := make([]byte, centralHeaderSize)
+	binary.LittleEndian.PutUint32(b, centralHeaderSignature)
+	binary.LittleEndian.PutUint16(b[6:], versionNeeded)
+	binary.LittleEndian.PutUint16(b[8:], flagMaxDeflate)
+	binary.LittleEndian.PutUint16(b[10:], zip.Deflate)
+	binary.LittleEndian.PutUint16(b[12:], dosTime)
+	binary.LittleEndian.PutUint16(b[14:], dosDate)
+	binary.LittleEndian.PutUint32(b[16:], e.crc)
+	binary.LittleEndian.PutUint32(b[20:], e.compressedSize)
+	binary.LittleEndian.PutUint32(b[24:], e.uncompressedSize)
+	binary.LittleEndian.PutUint16(b[28:], uint16(len(e.name)))
+	binary.LittleEndian.PutUint32(b[42:], e.offset)
+	buf.Write(b)
+	buf.WriteString(e.name)
+}
+
+// Verify checks that the archive at path is TorrentZipped: its comment
+// must hold the checksum of the central directory, and its entries must be
+// sorted, deflated and carry the fixed timestamp. Archives which are not
+// TorrentZipped are reported with ErrNotTorrentZip and the reason.
+func Verify(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size < endRecordSize+int64(commentLength) {
+		return fmt.Errorf("%w: missing TorrentZip comment", ErrNotTorrentZip)
+	}
+
+	end := make([]byte, endRecordSize+commentLength)
+	if _, err := f.ReadAt(end, size-int64(len(end))); err != nil {
+		return err
+	}
+	comment := string(end[endRecordSize:])
+	if binary.LittleEndian.Uint32(end) != endRecordSignature ||
+		int(binary.LittleEndian.Uint16(end[20:])) != commentLength ||
+		!strings.HasPrefix(comment, commentPrefix) {
+		return fmt.Errorf("%w: missing TorrentZip comment", ErrNotTorrentZip)
+	}
+
+	centralSize := int64(binary.LittleEndian.Uint32(end[12:]))
+	centralOffset := int64(binary.LittleEndian.Uint32(end[16:]))
+	if centralOffset+centralSize > size {
+		return fmt.Errorf("%w: invalid central directory", ErrNotTorrentZip)
+	}
+	central := make([]byte, centralSize)
+	if _, err := f.ReadAt(central, centralOffset); err != nil {
+		return err
+	}
+	if want := fmt.Sprintf("%08X", crc32.ChecksumIEEE(central)); comment[len(commentPrefix):] != want {
+		return fmt.Errorf("%w: comment checksum %s, want %s", ErrNotTorrentZip, comment[len(commentPrefix):], want)
+	}
+
+	r, err := zip.NewReader(f, size)
+	if err != nil {
+		return err
+	}
+	return verifyEntries(r.File)
+}
+
+func verifyEntries(files []*zip.File) error {
+	for i, file := range files {
+		switch {
+		case file.Method != zip.Deflate:
+			return fmt.Errorf("%w: '%s' is not deflated", ErrNotTorrentZip, file.Name)
+		case file.ModifiedTime != dosTime || file.ModifiedDate != dosDate:
+			return fmt.Errorf("%w: '%s' has a non-standard timestamp", ErrNotTorrentZip, file.Name)
+		case len(file.Extra) > 0 || file.Comment != "":
+			return fmt.Errorf("%w: '%s' has extra fields", ErrNotTorrentZip, file.Name)
+		case i > 0 && strings.ToLower(files[i-1].Name) > strings.ToLower(file.Name):
+			return fmt.Errorf("%w: '%s' is not sorted", ErrNotTorrentZip, file.Name)
+		}
+	}
+	return nil
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
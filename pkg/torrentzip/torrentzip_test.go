@@ -0,0 +1,145 @@
+package torrentzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func stringSource(name, content string) Source {
+	return Source{Name: name, Open: func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}}
+}
+
+func TestCreate(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	sources := []Source{
+		stringSource("b.d64", strings.Repeat("disk b", 100)),
+		stringSource("A.d64", "disk a"),
+		stringSource("empty.d64", ""),
+	}
+	first := filepath.Join(tmpDir, "first.zip")
+	second := filepath.Join(tmpDir, "second.zip")
+	if err := Create(first, sources); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := Create(second, []Source{sources[2], sources[0], sources[1]}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+	if !bytes.Equal(a, b) {
+		t.Error("Create() is not deterministic")
+	}
+	if err := Verify(first); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	r, err := zip.OpenReader(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, rc); err != nil {
+			t.Errorf("reading %q: %v", f.Name, err)
+		}
+		rc.Close()
+	}
+	if strings.Join(names, ",") != "A.d64,b.d64,empty.d64" {
+		t.Errorf("entries = %v, want sorted by lowercase name", names)
+	}
+	if !strings.HasPrefix(r.Comment, "TORRENTZIPPED-") {
+		t.Errorf("comment = %q", r.Comment)
+	}
+
+	if err := Create(first, sources); err == nil {
+		t.Error("Create() overwrote an existing file")
+	}
+	if err := Create(filepath.Join(tmpDir, "dup.zip"), []Source{sources[0], sources[0]}); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Create() error = %v, want %v", err, ErrDuplicateName)
+	}
+	dups := []Source{stringSource("x", "1"), stringSource("X", "2"), stringSource("x", "3")}
+	if err := Create(filepath.Join(tmpDir, "dup2.zip"), dups); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Create() error = %v, want %v", err, ErrDuplicateName)
+	}
+}
+
+// TestCreateGolden rebuilds a TorrentZip archive written with zlib at
+// level 9 from its own entries and expects the very same bytes.
+func TestCreateGolden(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	golden := filepath.Join("testdata", "golden.zip")
+	r, err := zip.OpenReader(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var sources []Source
+	for _, f := range r.File {
+		sources = append(sources, Source{Name: f.Name, Open: f.Open})
+	}
+	rebuilt := filepath.Join(tmpDir, "rebuilt.zip")
+	if err := Create(rebuilt, sources); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	want, _ := os.ReadFile(golden)
+	got, _ := os.ReadFile(rebuilt)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Create() wrote %d bytes differing from the %d bytes of %s", len(got), len(want), golden)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	plain := filepath.Join(tmpDir, "plain.zip")
+	f, err := os.Create(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	if _, err := w.Create("a.d64"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	f.Close()
+
+	tampered := filepath.Join(tmpDir, "tampered.zip")
+	if err := Create(tampered, []Source{stringSource("a.d64", "a")}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(tampered)
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(tampered, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{plain, tampered} {
+		if err := Verify(path); !errors.Is(err, ErrNotTorrentZip) {
+			t.Errorf("Verify(%s) error = %v, want %v", filepath.Base(path), err, ErrNotTorrentZip)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package tosec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/climbus/retro-romkit/internal/tree"
+	"github.com/climbus/retro-romkit/pkg/archive"
+)
+
+// CopyAction describes copying one file, or extracting one archive member,
+// to its target path.
+type CopyAction struct {
+	Source string // Path of the file or archive in the folder
+	Member string // Path of the member inside the archive, empty for plain files
+	Target string // Destination path
+}
+
+// PlanCopy proposes copying every file in the folder to opts.Output,
+// keeping its path relative to the folder. With opts.Unzip, archives are
+// extracted into the directory holding them instead; only
+// members matching the folder's FileTypes are extracted. With opts.Limit
+// set, at most that many files are placed in each target directory.
+// Members with unsafe paths and files whose target already exists, or is
+// used by another file, are returned as parse errors.
+func (tosecFolder *Folder) PlanCopy(opts CopyOptions) ([]CopyAction, []ParseError, error) {
+	actions := make([]CopyAction, 0)
+	var skipped []ParseError
+	targets := make(map[string]bool)
+	dirCounts := make(map[string]int)
+
+	plan := func(name string, action CopyAction) {
+		if targets[action.Target] || fileExists(action.Target) {
+			skipped = append(skipped, ParseError{FileName: name, Error: fmt.Errorf("%w: %s", ErrTargetExists, action.Target)})
+			return
+		}
+		dir := filepath.Dir(action.Target)
+		if opts.Limit > 0 && dirCounts[dir] >= opts.Limit {
+			return
+		}
+		dirCounts[dir]++
+		targets[action.Target] = true
+		actions = append(actions, action)
+	}
+
+	// Archives are extracted by Unzip, so they are always walked as files
+	entries, errCh := tosecFolder.walkFiles()
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		dir := filepath.Join(opts.Output, entry.Folder)
+		if !opts.Unzip || !archive.IsArchive(entry.Path) {
+			plan(entry.Name, CopyAction{Source: entry.Path, Target: filepath.Join(dir, entry.Name)})
+			continue
+		}
+
+		err := archive.Walk(entry.Path, func(f archive.File, _ io.Reader) error {
+			if !tree.HasOneOfFileTypes(f.Name, tosecFolder.FileTypes) {
+				return nil
+			}
+			name := filepath.Join(entry.Name, filepath.FromSlash(f.Name))
+			target, err := safeJoin(dir, f.Name)
+			if err != nil {
+				skipped = append(skipped, ParseError{FileName: name, Error: err})
+				return nil
+			}
+			plan(name, CopyAction{Source: entry.Path, Member: f.Name, Target: target})
+			return nil
+		})
+		if err != nil {
+			skipped = append(skipped, ParseError{FileName: entry.Name, Error: err})
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+
+	return actions, skipped, nil
+}
+
+// ApplyCopy carries out the planned actions. Archives are read once for
+// all of their planned members. Existing files are never overwritten.
+func ApplyCopy(actions []CopyAction) error {
+	members := make(map[string]map[string]string)
+	for _, action := range actions {
+		if fileExists(action.Target) {
+			return fmt.Errorf("%w: %s", ErrTargetExists, action.Target)
+		}
+		if err := os.MkdirAll(filepath.Dir(action.Target), 0755); err != nil {
+			return err
+		}
+		if action.Member == "" {
+			if err := copyFile(action.Source, action.Target); err != nil {
+				return fmt.Errorf("failed to copy '%s': %w", action.Source, err)
+			}
+			continue
+		}
+		if members[action.Source] == nil {
+			members[action.Source] = make(map[string]string)
+		}
+		members[action.Source][action.Member] = action.Target
+	}
+
+	for source, targets := range members {
+		err := archive.Walk(source, func(f archive.File, r io.Reader) error {
+			target, ok := targets[f.Name]
+			if !ok {
+				return nil
+			}
+			delete(targets, f.Name) // Only the first of duplicate members is extracted
+			return writeNewFile(target, r)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to extract '%s': %w", source, err)
+		}
+	}
+	return nil
+}
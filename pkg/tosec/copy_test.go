@@ -0,0 +1,82 @@
+package tosec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestPlanCopyUnzip(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	output := filepath.Join(tmpDir, "output")
+
+	writeTestFile(t, filepath.Join(source, "Exolon (1987)(Hewson).d64"), "exolon")
+	if err := os.MkdirAll(filepath.Join(source, "sets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	testutils.CreateTestZip(t, filepath.Join(source, "sets", "Zynaps.zip"), map[string]string{
+		"Zynaps (1987)(Hewson)(Disk 1 of 2).d64": "disk1",
+		"Zynaps (1987)(Hewson)(Disk 2 of 2).d64": "disk2",
+		"readme.txt":                             "readme",
+	})
+	testutils.CreateTestZip(t, filepath.Join(source, "sets", "Copy.zip"), map[string]string{
+		"Zynaps (1987)(Hewson)(Disk 1 of 2).d64": "other",
+	})
+	testutils.CreateTestTar(t, filepath.Join(source, "evil.tar"), map[string]string{"../evil.d64": "evil"}, false)
+
+	folder := Create(source, "c64")
+	actions, skipped, err := folder.PlanCopy(CopyOptions{Output: output, Unzip: true})
+	if err != nil {
+		t.Fatalf("PlanCopy() error = %v", err)
+	}
+
+	if len(actions) != 3 {
+		t.Fatalf("PlanCopy() = %+v, want 3 actions", actions)
+	}
+	if len(skipped) != 2 {
+		t.Fatalf("PlanCopy() skipped = %+v, want the unsafe and the colliding member", skipped)
+	}
+	for _, s := range skipped {
+		if filepath.Base(s.FileName) == "Zynaps (1987)(Hewson)(Disk 1 of 2).d64" && !errors.Is(s.Error, ErrTargetExists) {
+			t.Errorf("PlanCopy() skipped %q with %v, want %v", s.FileName, s.Error, ErrTargetExists)
+		}
+	}
+
+	if err := ApplyCopy(actions); err != nil {
+		t.Fatalf("ApplyCopy() error = %v", err)
+	}
+	want := map[string]string{
+		"Exolon (1987)(Hewson).d64":                                     "exolon",
+		filepath.Join("sets", "Zynaps (1987)(Hewson)(Disk 1 of 2).d64"): "other",
+		filepath.Join("sets", "Zynaps (1987)(Hewson)(Disk 2 of 2).d64"): "disk2",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(output, name))
+		if err != nil || string(data) != content {
+			t.Errorf("copied %q = %q, %v, want %q", name, data, err, content)
+		}
+	}
+	if fileExists(filepath.Join(output, "sets", "readme.txt")) || fileExists(filepath.Join(tmpDir, "evil.d64")) {
+		t.Error("ApplyCopy() extracted a filtered or unsafe member")
+	}
+}
+
+func TestPlanCopyLimit(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{"a.d64", "b.d64", "c.d64", "sub/d.d64"}, tmpDir)
+
+	actions, _, err := Create(tmpDir, "c64").PlanCopy(CopyOptions{Output: filepath.Join(tmpDir, "out"), Limit: 2, Unzip: true})
+	if err != nil {
+		t.Fatalf("PlanCopy() error = %v", err)
+	}
+	if len(actions) != 3 {
+		t.Errorf("PlanCopy() = %+v, want 2 files from the root and 1 from sub", actions)
+	}
+}
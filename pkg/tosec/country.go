@@ -0,0 +1,139 @@
+package tosec
+
+import (
+	"slices"
+	"strings"
+)
+
+// Region groups used to bucket countries.
+const (
+	RegionAfrica       = "Africa"
+	RegionAsia         = "Asia"
+	RegionEurope       = "Europe"
+	RegionMiddleEast   = "Middle East"
+	RegionNorthAmerica = "North America"
+	RegionOceania      = "Oceania"
+	RegionSouthAmerica = "South America"
+)
+
+// Country represents a country from the TOSEC country field.
+type Country struct {
+	Code     string
+	Name     string
+	Region   string
+	Language string // Default language code, empty when ambiguous
+}
+
+// Countries maps ISO 3166-1 alpha-2 codes (plus the TOSEC specific "EU")
+// to their country definitions.
+var Countries = map[string]Country{
+	"AE": {"AE", "United Arab Emirates", RegionMiddleEast, "ar"},
+	"AL": {"AL", "Albania", RegionEurope, "sq"},
+	"AS": {"AS", "Asia", RegionAsia, ""},
+	"AT": {"AT", "Austria", RegionEurope, "de"},
+	"AU": {"AU", "Australia", RegionOceania, "en"},
+	"BA": {"BA", "Bosnia and Herzegovina", RegionEurope, "bs"},
+	"BE": {"BE", "Belgium", RegionEurope, ""},
+	"BG": {"BG", "Bulgaria", RegionEurope, "bg"},
+	"BR": {"BR", "Brazil", RegionSouthAmerica, "pt"},
+	"CA": {"CA", "Canada", RegionNorthAmerica, "en"},
+	"CH": {"CH", "Switzerland", RegionEurope, "de"},
+	"CL": {"CL", "Chile", RegionSouthAmerica, "es"},
+	"CN": {"CN", "China", RegionAsia, "zh"},
+	"CS": {"CS", "Serbia and Montenegro", RegionEurope, "sr"},
+	"CY": {"CY", "Cyprus", RegionEurope, "el"},
+	"CZ": {"CZ", "Czech Republic", RegionEurope, "cs"},
+	"DE": {"DE", "Germany", RegionEurope, "de"},
+	"DK": {"DK", "Denmark", RegionEurope, "da"},
+	"EE": {"EE", "Estonia", RegionEurope, "et"},
+	"EG": {"EG", "Egypt", RegionAfrica, "ar"},
+	"ES": {"ES", "Spain", RegionEurope, "es"},
+	"EU": {"EU", "Europe", RegionEurope, ""},
+	"FI": {"FI", "Finland", RegionEurope, "fi"},
+	"FR": {"FR", "France", RegionEurope, "fr"},
+	"GB": {"GB", "United Kingdom", RegionEurope, "en"},
+	"GR": {"GR", "Greece", RegionEurope, "el"},
+	"HK": {"HK", "Hong Kong", RegionAsia, "zh"},
+	"HR": {"HR", "Croatia", RegionEurope, "hr"},
+	"HU": {"HU", "Hungary", RegionEurope, "hu"},
+	"ID": {"ID", "Indonesia", RegionAsia, "id"},
+	"IE": {"IE", "Ireland", RegionEurope, "en"},
+	"IL": {"IL", "Israel", RegionMiddleEast, "he"},
+	"IN": {"IN", "India", RegionAsia, "hi"},
+	"IR": {"IR", "Iran", RegionMiddleEast, "fa"},
+	"IS": {"IS", "Iceland", RegionEurope, "is"},
+	"IT": {"IT", "Italy", RegionEurope, "it"},
+	"JO": {"JO", "Jordan", RegionMiddleEast, "ar"},
+	"JP": {"JP", "Japan", RegionAsia, "ja"},
+	"KR": {"KR", "South Korea", RegionAsia, "ko"},
+	"LT": {"LT", "Lithuania", RegionEurope, "lt"},
+	"LU": {"LU", "Luxembourg", RegionEurope, ""},
+	"LV": {"LV", "Latvia", RegionEurope, "lv"},
+	"MN": {"MN", "Mongolia", RegionAsia, "mn"},
+	"MX": {"MX", "Mexico", RegionNorthAmerica, "es"},
+	"MY": {"MY", "Malaysia", RegionAsia, "ms"},
+	"NL": {"NL", "Netherlands", RegionEurope, "nl"},
+	"NO": {"NO", "Norway", RegionEurope, "no"},
+	"NP": {"NP", "Nepal", RegionAsia, "ne"},
+	"NZ": {"NZ", "New Zealand", RegionOceania, "en"},
+	"OM": {"OM", "Oman", RegionMiddleEast, "ar"},
+	"PE": {"PE", "Peru", RegionSouthAmerica, "es"},
+	"PH": {"PH", "Philippines", RegionAsia, "en"},
+	"PL": {"PL", "Poland", RegionEurope, "pl"},
+	"PT": {"PT", "Portugal", RegionEurope, "pt"},
+	"QA": {"QA", "Qatar", RegionMiddleEast, "ar"},
+	"RO": {"RO", "Romania", RegionEurope, "ro"},
+	"RU": {"RU", "Russia", RegionEurope, "ru"},
+	"SE": {"SE", "Sweden", RegionEurope, "sv"},
+	"SG": {"SG", "Singapore", RegionAsia, "en"},
+	"SI": {"SI", "Slovenia", RegionEurope, "sl"},
+	"SK": {"SK", "Slovakia", RegionEurope, "sk"},
+	"TH": {"TH", "Thailand", RegionAsia, "th"},
+	"TR": {"TR", "Turkey", RegionEurope, "tr"},
+	"TW": {"TW", "Taiwan", RegionAsia, "zh"},
+	"US": {"US", "United States", RegionNorthAmerica, "en"},
+	"VN": {"VN", "Vietnam", RegionAsia, "vi"},
+	"YU": {"YU", "Yugoslavia", RegionEurope, "sr"},
+	"ZA": {"ZA", "South Africa", RegionAfrica, "en"},
+}
+
+// ParseCountries parses a single or hyphen-joined list of country codes,
+// e.g. "US" or "GB-FR". It returns false if any of the codes is unknown.
+func ParseCountries(value string) ([]Country, bool) {
+	codes := strings.Split(value, "-")
+	countries := make([]Country, 0, len(codes))
+	for _, code := range codes {
+		country, ok := Countries[code]
+		if !ok {
+			return nil, false
+		}
+		countries = append(countries, country)
+	}
+	return countries, true
+}
+
+func isCountryList(value string) bool {
+	_, ok := ParseCountries(value)
+	return ok
+}
+
+// CountryCodes returns the country codes of the file joined with a hyphen,
+// as they appear in the file name.
+func (tf *File) CountryCodes() string {
+	codes := make([]string, len(tf.Countries))
+	for i, country := range tf.Countries {
+		codes[i] = country.Code
+	}
+	return strings.Join(codes, "-")
+}
+
+// Regions returns the distinct region groups of the file's countries.
+func (tf *File) Regions() []string {
+	regions := make([]string, 0, len(tf.Countries))
+	for _, country := range tf.Countries {
+		if !slices.Contains(regions, country.Region) {
+			regions = append(regions, country.Region)
+		}
+	}
+	return regions
+}
@@ -0,0 +1,55 @@
+package tosec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCountries(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantCodes []string
+		wantOk    bool
+	}{
+		{"single country", "US", []string{"US"}, true},
+		{"multiple countries", "GB-FR-DE", []string{"GB", "FR", "DE"}, true},
+		{"europe", "EU", []string{"EU"}, true},
+		{"unknown code", "XX", nil, false},
+		{"one unknown code", "US-XX", nil, false},
+		{"language code", "en", nil, false},
+		{"spelled out name", "Europe", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseCountries(tt.value)
+			if ok != tt.wantOk {
+				t.Fatalf("ParseCountries(%q) ok = %v, want %v", tt.value, ok, tt.wantOk)
+			}
+			var codes []string
+			for _, country := range got {
+				codes = append(codes, country.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("ParseCountries(%q) = %v, want %v", tt.value, codes, tt.wantCodes)
+			}
+		})
+	}
+}
+
+func TestFileRegions(t *testing.T) {
+	tf, err := ParseFileName("Zynaps (1987)(Hewson Consultants)(US-GB-DE-JP).zip")
+	if err != nil {
+		t.Fatalf("ParseFileName() failed: %v", err)
+	}
+
+	if got := tf.CountryCodes(); got != "US-GB-DE-JP" {
+		t.Errorf("CountryCodes() = %q, want %q", got, "US-GB-DE-JP")
+	}
+
+	want := []string{RegionNorthAmerica, RegionEurope, RegionAsia}
+	if got := tf.Regions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Regions() = %v, want %v", got, want)
+	}
+}
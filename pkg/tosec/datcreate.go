@@ -0,0 +1,80 @@
+package tosec
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+)
+
+// ErrDuplicateRom is returned when two files would be described by roms of
+// the same name in one game.
+var ErrDuplicateRom = errors.New("duplicate rom name in game")
+
+// CreateDat hashes every file in the folder and describes it in a DAT with
+// the given header. Files are grouped into one game per parsed title, so all
+// media of a multi-disk set share a game. Files which cannot be parsed are
+// returned as parse errors and get a game named after the file; files which
+// cannot be hashed, or whose rom name is already used in their game, such
+// as same-named files in different subfolders, are returned as parse
+// errors and left out.
+func (tosecFolder *Folder) CreateDat(header dat.Header) (*dat.Datafile, []ParseError, error) {
+	games := make(map[string]*dat.Game)
+	var parseErrors []ParseError
+
+	entries, errCh := tosecFolder.GetHashedFileTree()
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if entry.Err != nil {
+			parseErrors = append(parseErrors, ParseError{FileName: entry.Name, Error: entry.Err})
+			continue
+		}
+		for _, sums := range entry.Sums {
+			gameName := strings.TrimSuffix(sums.Name, filepath.Ext(sums.Name))
+			if tf, err := ParseWithSchemes(filepath.Base(sums.Name), tosecFolder.namingSchemes()); err == nil {
+				gameName = tf.GameName()
+			} else {
+				parseErrors = append(parseErrors, ParseError{FileName: sums.Name, Error: err})
+			}
+
+			game, ok := games[gameName]
+			if !ok {
+				game = &dat.Game{Name: gameName, Description: gameName}
+				games[gameName] = game
+			}
+			if slices.ContainsFunc(game.Roms, func(rom dat.Rom) bool { return rom.Name == sums.Name }) {
+				parseErrors = append(parseErrors, ParseError{
+					FileName: filepath.Join(entry.Folder, entry.Name),
+					Error:    fmt.Errorf("%w: %s in %s", ErrDuplicateRom, sums.Name, gameName),
+				})
+				continue
+			}
+			game.Roms = append(game.Roms, dat.Rom{
+				Name: sums.Name,
+				Size: sums.Size,
+				CRC:  sums.CRC32,
+				MD5:  sums.MD5,
+				SHA1: sums.SHA1,
+			})
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+
+	datafile := &dat.Datafile{Header: header}
+	for _, game := range games {
+		slices.SortFunc(game.Roms, func(a, b dat.Rom) int { return cmp.Compare(a.Name, b.Name) })
+		datafile.Games = append(datafile.Games, *game)
+	}
+	slices.SortFunc(datafile.Games, func(a, b dat.Game) int { return cmp.Compare(a.Name, b.Name) })
+
+	return datafile, parseErrors, nil
+}
@@ -0,0 +1,94 @@
+package tosec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestCreateDat(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "Zynaps (1987)(Hewson).d64"), "hello")
+	writeTestFile(t, filepath.Join(tmpDir, "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64"), "disk two")
+	writeTestFile(t, filepath.Join(tmpDir, "sub", "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64"), "disk one")
+	writeTestFile(t, filepath.Join(tmpDir, "unparsable.d64"), "")
+	writeTestFile(t, filepath.Join(tmpDir, "Maniac Mansion (Europe) (Disk 1).d64"), "maniac one")
+	writeTestFile(t, filepath.Join(tmpDir, "Maniac Mansion (Europe) (Disk 2).d64"), "maniac two")
+
+	header := dat.Header{Name: "My C64 Collection", Description: "Favourites", Version: "1.0", Author: "me"}
+	datafile, parseErrors, err := Create(tmpDir, "c64").CreateDat(header)
+	if err != nil {
+		t.Fatalf("CreateDat() error = %v", err)
+	}
+
+	if datafile.Header != header {
+		t.Errorf("Header = %+v, want %+v", datafile.Header, header)
+	}
+	if len(parseErrors) != 1 || parseErrors[0].FileName != "unparsable.d64" {
+		t.Errorf("parse errors = %+v, want unparsable.d64", parseErrors)
+	}
+
+	wantGames := []struct {
+		name string
+		roms []string
+	}{
+		{"Last Ninja, The (1987)(System 3)", []string{
+			"Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64",
+			"Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64",
+		}},
+		{"Maniac Mansion (Europe)", []string{
+			"Maniac Mansion (Europe) (Disk 1).d64",
+			"Maniac Mansion (Europe) (Disk 2).d64",
+		}},
+		{"Zynaps (1987)(Hewson)", []string{"Zynaps (1987)(Hewson).d64"}},
+		{"unparsable", []string{"unparsable.d64"}},
+	}
+	if len(datafile.Games) != len(wantGames) {
+		t.Fatalf("len(Games) = %d, want %d: %+v", len(datafile.Games), len(wantGames), datafile.Games)
+	}
+	for i, want := range wantGames {
+		game := datafile.Games[i]
+		if game.Name != want.name {
+			t.Errorf("Games[%d].Name = %q, want %q", i, game.Name, want.name)
+		}
+		if len(game.Roms) != len(want.roms) {
+			t.Errorf("Games[%d] has %d roms, want %d", i, len(game.Roms), len(want.roms))
+			continue
+		}
+		for j, rom := range want.roms {
+			if game.Roms[j].Name != rom {
+				t.Errorf("Games[%d].Roms[%d].Name = %q, want %q", i, j, game.Roms[j].Name, rom)
+			}
+		}
+	}
+
+	zynaps := datafile.Games[2].Roms[0]
+	if zynaps.Size != 5 || zynaps.CRC != "3610a686" || zynaps.SHA1 != "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d" {
+		t.Errorf("Zynaps rom = %+v", zynaps)
+	}
+}
+
+func TestCreateDatDuplicateRomNames(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "a", "Zynaps (1987)(Hewson).d64"), "hello")
+	writeTestFile(t, filepath.Join(tmpDir, "b", "Zynaps (1987)(Hewson).d64"), "other")
+
+	datafile, parseErrors, err := Create(tmpDir, "c64").CreateDat(dat.Header{Name: "Duplicates"})
+	if err != nil {
+		t.Fatalf("CreateDat() error = %v", err)
+	}
+	if len(datafile.Games) != 1 || len(datafile.Games[0].Roms) != 1 {
+		t.Errorf("Games = %+v, want one game with one rom", datafile.Games)
+	}
+	if len(parseErrors) != 1 || !errors.Is(parseErrors[0].Error, ErrDuplicateRom) {
+		t.Errorf("parse errors = %+v, want one ErrDuplicateRom", parseErrors)
+	}
+}
@@ -0,0 +1,211 @@
+package tosec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateWildcard = 'x'
+
+// ErrInvalidDate is returned when a TOSEC date field is malformed or out of range.
+var ErrInvalidDate = errors.New("invalid date")
+
+// DatePrecision describes which components of a date are present.
+type DatePrecision int
+
+const (
+	PrecisionNone DatePrecision = iota
+	PrecisionYear
+	PrecisionMonth
+	PrecisionDay
+)
+
+// Date represents a TOSEC date field. Any digit may be replaced by an 'x'
+// wildcard when it is unknown, e.g. "19xx", "198x" or "1987-0x".
+type Date struct {
+	year  string
+	month string
+	day   string
+}
+
+// ParseDate parses a TOSEC date in the form YYYY, YYYY-MM or YYYY-MM-DD.
+func ParseDate(value string) (Date, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) > 3 {
+		return Date{}, fmt.Errorf("%w '%s': too many components", ErrInvalidDate, value)
+	}
+
+	d := Date{year: parts[0]}
+	if len(parts) > 1 {
+		d.month = parts[1]
+	}
+	if len(parts) > 2 {
+		d.day = parts[2]
+	}
+
+	if err := d.validate(); err != nil {
+		return Date{}, fmt.Errorf("%w '%s': %v", ErrInvalidDate, value, err)
+	}
+	return d, nil
+}
+
+// String returns the date in its TOSEC representation.
+func (d Date) String() string {
+	parts := []string{d.year}
+	if d.month != "" {
+		parts = append(parts, d.month)
+	}
+	if d.day != "" {
+		parts = append(parts, d.day)
+	}
+	return strings.Join(parts, "-")
+}
+
+// IsZero reports whether the date is empty.
+func (d Date) IsZero() bool {
+	return d.year == ""
+}
+
+// Precision returns the most specific component present in the date.
+func (d Date) Precision() DatePrecision {
+	switch {
+	case d.day != "":
+		return PrecisionDay
+	case d.month != "":
+		return PrecisionMonth
+	case d.year != "":
+		return PrecisionYear
+	}
+	return PrecisionNone
+}
+
+// IsExact reports whether the date contains no wildcards.
+func (d Date) IsExact() bool {
+	return !d.IsZero() && !strings.ContainsRune(d.String(), dateWildcard)
+}
+
+// Year returns the year, or false if it is not fully known.
+func (d Date) Year() (int, bool) {
+	return parseKnown(d.year)
+}
+
+// Decade returns the first year of the decade, e.g. 1980 for "198x".
+func (d Date) Decade() (int, bool) {
+	if len(d.year) != 4 {
+		return 0, false
+	}
+	decade, ok := parseKnown(d.year[:3])
+	return decade * 10, ok
+}
+
+// Month returns the month, or false if it is not fully known.
+func (d Date) Month() (time.Month, bool) {
+	month, ok := parseKnown(d.month)
+	return time.Month(month), ok
+}
+
+// Day returns the day of the month, or false if it is not fully known.
+func (d Date) Day() (int, bool) {
+	return parseKnown(d.day)
+}
+
+// Compare returns -1, 0 or +1 depending on whether d sorts before, equal to
+// or after other. Unknown components sort before known ones.
+func (d Date) Compare(other Date) int {
+	return strings.Compare(d.sortKey(), other.sortKey())
+}
+
+// Before reports whether d sorts before other.
+func (d Date) Before(other Date) bool {
+	return d.Compare(other) < 0
+}
+
+func (d Date) sortKey() string {
+	key := fmt.Sprintf("%-4s%-2s%-2s", d.year, d.month, d.day)
+	return strings.Map(func(r rune) rune {
+		if r == dateWildcard || r == ' ' {
+			return '0' - 1
+		}
+		return r
+	}, key)
+}
+
+func (d Date) validate() error {
+	if err := validateComponent(d.year, 4, "year"); err != nil {
+		return err
+	}
+	if d.year[0] != '1' && d.year[0] != '2' {
+		return errors.New("year out of range")
+	}
+	if d.month != "" {
+		if err := validateComponent(d.month, 2, "month"); err != nil {
+			return err
+		}
+		if !componentInRange(d.month, 1, 12) {
+			return errors.New("month out of range")
+		}
+	}
+	if d.day != "" {
+		if err := validateComponent(d.day, 2, "day"); err != nil {
+			return err
+		}
+		if !componentInRange(d.day, 1, d.daysInMonth()) {
+			return errors.New("day out of range")
+		}
+	}
+	return nil
+}
+
+func (d Date) daysInMonth() int {
+	month, ok := d.Month()
+	if !ok {
+		return 31
+	}
+	year, ok := d.Year()
+	if !ok {
+		// Assume a leap year so that February 29th is accepted
+		year = 2000
+	}
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// validateComponent checks the length of a date component and makes sure
+// wildcards only replace trailing digits.
+func validateComponent(value string, length int, name string) error {
+	if len(value) != length {
+		return fmt.Errorf("%s must have %d digits", name, length)
+	}
+	wildcard := false
+	for _, r := range value {
+		switch {
+		case r == dateWildcard:
+			wildcard = true
+		case r >= '0' && r <= '9' && !wildcard:
+		default:
+			return fmt.Errorf("unexpected character in %s", name)
+		}
+	}
+	if value[0] == dateWildcard && name == "year" {
+		return errors.New("year must start with a digit")
+	}
+	return nil
+}
+
+// componentInRange checks that the range of values a possibly wildcarded
+// component can take overlaps with [low, high].
+func componentInRange(value string, low, high int) bool {
+	minValue, _ := strconv.Atoi(strings.ReplaceAll(value, string(dateWildcard), "0"))
+	maxValue, _ := strconv.Atoi(strings.ReplaceAll(value, string(dateWildcard), "9"))
+	return maxValue >= low && minValue <= high
+}
+
+func parseKnown(value string) (int, bool) {
+	if value == "" || strings.ContainsRune(value, dateWildcard) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
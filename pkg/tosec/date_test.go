@@ -0,0 +1,118 @@
+package tosec
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value         string
+		wantErr       bool
+		wantPrecision DatePrecision
+		wantExact     bool
+	}{
+		{"1987", false, PrecisionYear, true},
+		{"198x", false, PrecisionYear, false},
+		{"19xx", false, PrecisionYear, false},
+		{"1987-04", false, PrecisionMonth, true},
+		{"1987-0x", false, PrecisionMonth, false},
+		{"1987-xx", false, PrecisionMonth, false},
+		{"1987-04-23", false, PrecisionDay, true},
+		{"1987-04-2x", false, PrecisionDay, false},
+		{"1988-02-29", false, PrecisionDay, true},
+		{"1987-02-29", true, 0, false},
+		{"1987-13", true, 0, false},
+		{"1987-00", true, 0, false},
+		{"1987-2x", true, 0, false},
+		{"1987-04-31", true, 0, false},
+		{"19x7", true, 0, false},
+		{"xxxx", true, 0, false},
+		{"87", true, 0, false},
+		{"3000", true, 0, false},
+		{"1987-04-23-01", true, 0, false},
+		{"abcd", true, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDate(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDate) {
+					t.Fatalf("ParseDate(%q) error = %v, want ErrInvalidDate", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q) failed: %v", tt.value, err)
+			}
+			if got.String() != tt.value {
+				t.Errorf("String() = %q, want %q", got.String(), tt.value)
+			}
+			if got.Precision() != tt.wantPrecision {
+				t.Errorf("Precision() = %v, want %v", got.Precision(), tt.wantPrecision)
+			}
+			if got.IsExact() != tt.wantExact {
+				t.Errorf("IsExact() = %v, want %v", got.IsExact(), tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestDateAccessors(t *testing.T) {
+	d, _ := ParseDate("1987-04")
+	if year, ok := d.Year(); !ok || year != 1987 {
+		t.Errorf("Year() = %d, %v, want 1987, true", year, ok)
+	}
+	if decade, ok := d.Decade(); !ok || decade != 1980 {
+		t.Errorf("Decade() = %d, %v, want 1980, true", decade, ok)
+	}
+	if month, ok := d.Month(); !ok || month != time.April {
+		t.Errorf("Month() = %v, %v, want April, true", month, ok)
+	}
+	if _, ok := d.Day(); ok {
+		t.Errorf("Day() should not be known")
+	}
+
+	wildcard, _ := ParseDate("198x")
+	if _, ok := wildcard.Year(); ok {
+		t.Errorf("Year() of %q should not be known", wildcard)
+	}
+	if decade, ok := wildcard.Decade(); !ok || decade != 1980 {
+		t.Errorf("Decade() = %d, %v, want 1980, true", decade, ok)
+	}
+
+	century, _ := ParseDate("19xx")
+	if _, ok := century.Decade(); ok {
+		t.Errorf("Decade() of %q should not be known", century)
+	}
+}
+
+func TestDateCompare(t *testing.T) {
+	ordered := []string{"19xx", "198x", "1987", "1987-04", "1987-04-01", "1987-05", "1988"}
+
+	for i := 1; i < len(ordered); i++ {
+		a, _ := ParseDate(ordered[i-1])
+		b, _ := ParseDate(ordered[i])
+		if !a.Before(b) {
+			t.Errorf("Expected %s to sort before %s", a, b)
+		}
+		if b.Compare(a) != 1 {
+			t.Errorf("Expected %s to sort after %s", b, a)
+		}
+	}
+
+	a, _ := ParseDate("1987-04")
+	b, _ := ParseDate("1987-04")
+	if a.Compare(b) != 0 {
+		t.Errorf("Expected equal dates to compare as 0")
+	}
+}
+
+func TestParseFileNameInvalidDate(t *testing.T) {
+	_, err := ParseFileName("Zynaps (1987-13)(Hewson Consultants).zip")
+	if !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("ParseFileName() error = %v, want ErrInvalidDate", err)
+	}
+}
@@ -0,0 +1,191 @@
+package tosec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const regexDumpFlag = `^(cr|tr|f|h|m|p|t|o|u|v|b|a|!)(\d*)(?:\s+(.*))?$`
+const regexTrainerCount = `^\+(\d+)$`
+
+var (
+	reDumpFlag     = regexp.MustCompile(regexDumpFlag)
+	reTrainerCount = regexp.MustCompile(regexTrainerCount)
+)
+
+// DumpKind identifies the kind of a TOSEC dump info flag.
+// The constants are declared in the order the TOSEC naming convention
+// requires the flags to appear in a file name.
+type DumpKind int
+
+const (
+	DumpCracked DumpKind = iota
+	DumpFixed
+	DumpHacked
+	DumpModified
+	DumpPirated
+	DumpTrained
+	DumpTranslated
+	DumpOverDump
+	DumpUnderDump
+	DumpVirus
+	DumpBad
+	DumpAlternate
+	DumpVerified
+)
+
+var dumpCodes = map[DumpKind]string{
+	DumpCracked:    "cr",
+	DumpFixed:      "f",
+	DumpHacked:     "h",
+	DumpModified:   "m",
+	DumpPirated:    "p",
+	DumpTrained:    "t",
+	DumpTranslated: "tr",
+	DumpOverDump:   "o",
+	DumpUnderDump:  "u",
+	DumpVirus:      "v",
+	DumpBad:        "b",
+	DumpAlternate:  "a",
+	DumpVerified:   "!",
+}
+
+var dumpNames = map[DumpKind]string{
+	DumpCracked:    "cracked",
+	DumpFixed:      "fixed",
+	DumpHacked:     "hacked",
+	DumpModified:   "modified",
+	DumpPirated:    "pirated",
+	DumpTrained:    "trained",
+	DumpTranslated: "translated",
+	DumpOverDump:   "over dump",
+	DumpUnderDump:  "under dump",
+	DumpVirus:      "virus",
+	DumpBad:        "bad dump",
+	DumpAlternate:  "alternate",
+	DumpVerified:   "verified good dump",
+}
+
+// Code returns the short code used inside brackets, e.g. "cr" or "!".
+func (k DumpKind) Code() string {
+	return dumpCodes[k]
+}
+
+// String returns a human readable name of the dump kind.
+func (k DumpKind) String() string {
+	if name, ok := dumpNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("DumpKind(%d)", int(k))
+}
+
+// DumpFlag represents a single parsed TOSEC dump info flag such as
+// [cr Fairlight], [a2], [t +3] or [tr de].
+type DumpFlag struct {
+	Kind     DumpKind
+	Number   int    // Sequence number, 0 when the flag is not numbered
+	Group    string // Cracking group, hacker, trainer or other attribution
+	Trainers int    // Number of trainers for [t +N] flags
+	Language string // Target language for [tr] flags
+	Raw      string
+}
+
+// ParseDumpFlag parses the content of a bracket flag (without the brackets).
+// It returns false when the flag is not a TOSEC dump info flag.
+func ParseDumpFlag(flag string) (DumpFlag, bool) {
+	flag = strings.TrimSpace(flag)
+	matches := reDumpFlag.FindStringSubmatch(flag)
+	if matches == nil {
+		return DumpFlag{}, false
+	}
+
+	kind, ok := dumpKindByCode(matches[1])
+	if !ok {
+		return DumpFlag{}, false
+	}
+
+	df := DumpFlag{Kind: kind, Raw: flag}
+	if matches[2] != "" {
+		df.Number, _ = strconv.Atoi(matches[2])
+	}
+
+	words := strings.Fields(matches[3])
+	if kind == DumpTranslated && len(words) > 0 {
+		df.Language = words[0]
+		words = words[1:]
+	}
+	if kind == DumpTrained {
+		words = df.extractTrainerCount(words)
+	}
+	df.Group = strings.Join(words, " ")
+
+	return df, true
+}
+
+// String formats the flag back into its bracket content, e.g. "cr2 Fairlight".
+func (df DumpFlag) String() string {
+	parts := []string{df.Kind.Code()}
+	if df.Number > 0 {
+		parts[0] += strconv.Itoa(df.Number)
+	}
+	if df.Language != "" {
+		parts = append(parts, df.Language)
+	}
+	if df.Trainers > 0 {
+		parts = append(parts, "+"+strconv.Itoa(df.Trainers))
+	}
+	if df.Group != "" {
+		parts = append(parts, df.Group)
+	}
+	return strings.Join(parts, " ")
+}
+
+// HasDump reports whether the file carries a dump flag of the given kind.
+func (tf *File) HasDump(kind DumpKind) bool {
+	_, ok := tf.Dump(kind)
+	return ok
+}
+
+// Dump returns the first dump flag of the given kind.
+func (tf *File) Dump(kind DumpKind) (DumpFlag, bool) {
+	for _, df := range tf.Dumps {
+		if df.Kind == kind {
+			return df, true
+		}
+	}
+	return DumpFlag{}, false
+}
+
+// IsOriginal reports whether the file is an unmodified dump, i.e. it carries
+// no flags other than alternate or verified.
+func (tf *File) IsOriginal() bool {
+	for _, df := range tf.Dumps {
+		if df.Kind != DumpAlternate && df.Kind != DumpVerified {
+			return false
+		}
+	}
+	return true
+}
+
+func (df *DumpFlag) extractTrainerCount(words []string) []string {
+	rest := make([]string, 0, len(words))
+	for _, word := range words {
+		if m := reTrainerCount.FindStringSubmatch(word); m != nil && df.Trainers == 0 {
+			df.Trainers, _ = strconv.Atoi(m[1])
+			continue
+		}
+		rest = append(rest, word)
+	}
+	return rest
+}
+
+func dumpKindByCode(code string) (DumpKind, bool) {
+	for kind, c := range dumpCodes {
+		if c == code {
+			return kind, true
+		}
+	}
+	return 0, false
+}
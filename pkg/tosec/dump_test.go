@@ -0,0 +1,65 @@
+package tosec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDumpFlag(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   string
+		want   DumpFlag
+		wantOk bool
+	}{
+		{"cracked with group", "cr Fairlight", DumpFlag{Kind: DumpCracked, Group: "Fairlight", Raw: "cr Fairlight"}, true},
+		{"numbered hack", "h2 Ikari", DumpFlag{Kind: DumpHacked, Number: 2, Group: "Ikari", Raw: "h2 Ikari"}, true},
+		{"numbered alternate", "a3", DumpFlag{Kind: DumpAlternate, Number: 3, Raw: "a3"}, true},
+		{"verified", "!", DumpFlag{Kind: DumpVerified, Raw: "!"}, true},
+		{"trainer count", "t +3", DumpFlag{Kind: DumpTrained, Trainers: 3, Raw: "t +3"}, true},
+		{"trainer count with group", "t2 +5 Triad", DumpFlag{Kind: DumpTrained, Number: 2, Trainers: 5, Group: "Triad", Raw: "t2 +5 Triad"}, true},
+		{"translation", "tr de", DumpFlag{Kind: DumpTranslated, Language: "de", Raw: "tr de"}, true},
+		{"translation with author", "tr pl Someone", DumpFlag{Kind: DumpTranslated, Language: "pl", Group: "Someone", Raw: "tr pl Someone"}, true},
+		{"bad dump", "b", DumpFlag{Kind: DumpBad, Raw: "b"}, true},
+		{"more info is not a dump flag", "Aka kota", DumpFlag{}, false},
+		{"docs is not a dump flag", "docs", DumpFlag{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDumpFlag(tt.flag)
+			if ok != tt.wantOk {
+				t.Fatalf("ParseDumpFlag(%q) ok = %v, want %v", tt.flag, ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDumpFlag(%q) = %+v, want %+v", tt.flag, got, tt.want)
+			}
+			if ok && got.String() != tt.flag {
+				t.Errorf("DumpFlag.String() = %q, want %q", got.String(), tt.flag)
+			}
+		})
+	}
+}
+
+func TestFileDumps(t *testing.T) {
+	tf, err := ParseFileName("Zynaps (1987)(Hewson Consultants)[cr Fairlight][t +2][a2][more info].d64")
+	if err != nil {
+		t.Fatalf("ParseFileName() failed: %v", err)
+	}
+
+	if len(tf.Dumps) != 3 {
+		t.Fatalf("Expected 3 dump flags, got %d: %+v", len(tf.Dumps), tf.Dumps)
+	}
+	if !tf.HasDump(DumpCracked) || !tf.HasDump(DumpTrained) || !tf.HasDump(DumpAlternate) {
+		t.Errorf("Expected cracked, trained and alternate flags, got %+v", tf.Dumps)
+	}
+	if tf.HasDump(DumpVerified) {
+		t.Errorf("Did not expect verified flag")
+	}
+	if trainer, _ := tf.Dump(DumpTrained); trainer.Trainers != 2 {
+		t.Errorf("Expected 2 trainers, got %d", trainer.Trainers)
+	}
+	if tf.IsOriginal() {
+		t.Errorf("Cracked file should not be reported as original")
+	}
+}
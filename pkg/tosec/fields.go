@@ -0,0 +1,93 @@
+package tosec
+
+import (
+	"slices"
+	"strings"
+)
+
+// optionField identifies one of the optional parenthesized fields. The
+// constants are declared in the order the fields appear in a file name.
+type optionField int
+
+const (
+	fieldNone optionField = iota
+	fieldSystem
+	fieldVideo
+	fieldCountry
+	fieldLanguage
+	fieldCopyright
+	fieldDevStatus
+	fieldMedia
+	fieldMediaLabel
+)
+
+var optionFieldNames = map[optionField]string{
+	fieldSystem:     "system",
+	fieldVideo:      "video",
+	fieldCountry:    "country",
+	fieldLanguage:   "language",
+	fieldCopyright:  "copyright",
+	fieldDevStatus:  "development status",
+	fieldMedia:      "media",
+	fieldMediaLabel: "media label",
+}
+
+func (f optionField) String() string {
+	return optionFieldNames[f]
+}
+
+// DemoTypes lists the values allowed in the TOSEC demo field.
+var DemoTypes = []string{"demo", "demo-kiosk", "demo-playable", "demo-rolling", "demo-slideshow"}
+
+// Systems lists the machine variants allowed in the TOSEC system field.
+// Several systems may be joined with a hyphen, e.g. "A500-A600".
+var Systems = []string{
+	"+2", "+2a", "+3", "16K", "48K", "64K", "128K", "130XE", "800XL",
+	"A1000", "A1200", "A2000", "A2024", "A2500", "A3000", "A3000UX", "A4000", "A4000T",
+	"A500", "A500+", "A570", "A600", "A600HD", "AGA", "CD32", "CDTV", "ECS", "OCS",
+	"C128", "C16", "Plus4", "VIC-20", "SX-64",
+	"Mega ST", "Mega-STE", "ST", "STE", "Falcon", "TT",
+	"PlayChoice-10", "VS DualSystem", "VS UniSystem",
+	"TURBO-R GT", "TURBO-R ST",
+}
+
+// VideoModes lists the values allowed in the TOSEC video field.
+var VideoModes = []string{"CGA", "EGA", "HGC", "MCGA", "MDA", "NTSC", "NTSC-PAL", "PAL", "PAL-60", "PAL-NTSC", "SECAM", "SVGA", "VGA", "XGA"}
+
+// CopyrightStatuses lists the values allowed in the TOSEC copyright status field.
+var CopyrightStatuses = []string{"CW", "CW-R", "FW", "GW", "GW-R", "LW", "PD", "SW", "SW-R", "SWR"}
+
+// DevelopmentStatuses lists the values allowed in the TOSEC development status field.
+var DevelopmentStatuses = []string{"alpha", "beta", "preview", "pre-release", "proto"}
+
+func isDemoType(value string) bool {
+	return slices.Contains(DemoTypes, value)
+}
+
+func isSystem(value string) bool {
+	if slices.Contains(Systems, value) {
+		return true
+	}
+	parts := strings.Split(value, "-")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !slices.Contains(Systems, part) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVideoMode(value string) bool {
+	return slices.Contains(VideoModes, value)
+}
+
+func isCopyrightStatus(value string) bool {
+	return slices.Contains(CopyrightStatuses, value)
+}
+
+func isDevelopmentStatus(value string) bool {
+	return slices.Contains(DevelopmentStatuses, value)
+}
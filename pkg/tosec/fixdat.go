@@ -0,0 +1,75 @@
+package tosec
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+)
+
+const fixdatPrefix = "fix_"
+
+// Fixdat returns a DAT containing only the roms of datafile reported as
+// missing, in the form understood by other ROM managers. Games without
+// missing roms are left out.
+func (r VerifyReport) Fixdat(datafile *dat.Datafile) *dat.Datafile {
+	missing := make(map[datEntry]bool)
+	for _, result := range r.Results {
+		if result.Status == StatusMissing && result.datafile == datafile {
+			missing[datEntry{datafile: datafile, game: result.Game, rom: result.Rom}] = true
+		}
+	}
+
+	fixdat := &dat.Datafile{Header: datafile.Header}
+	fixdat.Header.Name = fixdatPrefix + datafile.Header.Name
+	fixdat.Header.Description = fixdatPrefix + datafile.Header.Description
+	for _, game := range datafile.Games {
+		roms := slices.DeleteFunc(slices.Clone(game.Roms), func(rom dat.Rom) bool {
+			return !missing[datEntry{datafile: datafile, game: game.Name, rom: rom.Name}]
+		})
+		if len(roms) > 0 {
+			game.Roms = roms
+			fixdat.Games = append(fixdat.Games, game)
+		}
+	}
+	return fixdat
+}
+
+// WriteMissingList writes the missing roms as plain text, grouped by the
+// DAT they belong to and headed by its name, which usually names the
+// platform.
+func (r VerifyReport) WriteMissingList(w io.Writer) error {
+	var dats []*dat.Datafile
+	missing := make(map[*dat.Datafile][]string)
+	for _, result := range r.Results {
+		if result.Status != StatusMissing {
+			continue
+		}
+		if _, ok := missing[result.datafile]; !ok {
+			dats = append(dats, result.datafile)
+		}
+		missing[result.datafile] = append(missing[result.datafile], result.Rom)
+	}
+	slices.SortStableFunc(dats, func(a, b *dat.Datafile) int {
+		return strings.Compare(a.Header.Name, b.Header.Name)
+	})
+
+	for i, datafile := range dats {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s (%d missing)\n", datafile.Header.Name, len(missing[datafile])); err != nil {
+			return err
+		}
+		for _, rom := range missing[datafile] {
+			if _, err := fmt.Fprintf(w, "  %s\n", rom); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package tosec
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestFixdatAndMissingList(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{
+		"Zynaps (1987)(Hewson).d64",
+		"Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64",
+	}, tmpDir)
+
+	games := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Games", Description: "C64 Games"},
+		Games: []dat.Game{
+			{Name: "Zynaps (1987)(Hewson)", Roms: []dat.Rom{{Name: "Zynaps (1987)(Hewson).d64"}}},
+			{Name: "Last Ninja, The (1987)(System 3)", Roms: []dat.Rom{
+				{Name: "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64"},
+				{Name: "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64"},
+			}},
+			{Name: "Exolon (1987)(Hewson)", Roms: []dat.Rom{{Name: "Exolon (1987)(Hewson).d64"}}},
+		},
+	}
+	demos := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Demos", Description: "C64 Demos"},
+		Games: []dat.Game{
+			{Name: "Edge of Disgrace (2008)(Booze Design)", Roms: []dat.Rom{{Name: "Edge of Disgrace (2008)(Booze Design).d64"}}},
+		},
+	}
+
+	report, err := Create(tmpDir, "c64").Verify(games, demos)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	fixdat := report.Fixdat(games)
+	if fixdat.Header.Name != "fix_Commodore C64 - Games" {
+		t.Errorf("Fixdat() header name = %q", fixdat.Header.Name)
+	}
+	if len(fixdat.Games) != 2 {
+		t.Fatalf("Fixdat() has %d games, want 2: %+v", len(fixdat.Games), fixdat.Games)
+	}
+	if roms := fixdat.Games[0].Roms; len(roms) != 1 || roms[0].Name != "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64" {
+		t.Errorf("Fixdat() first game roms = %+v, want only disk 2", roms)
+	}
+	if fixdat.Games[1].Name != "Exolon (1987)(Hewson)" {
+		t.Errorf("Fixdat() second game = %q, want Exolon", fixdat.Games[1].Name)
+	}
+	if len(games.Games[1].Roms) != 2 {
+		t.Error("Fixdat() modified the source DAT")
+	}
+
+	var sb strings.Builder
+	if err := report.WriteMissingList(&sb); err != nil {
+		t.Fatalf("WriteMissingList() error = %v", err)
+	}
+	want := `Commodore C64 - Demos (1 missing)
+  Edge of Disgrace (2008)(Booze Design).d64
+
+Commodore C64 - Games (2 missing)
+  Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64
+  Exolon (1987)(Hewson).d64
+`
+	if sb.String() != want {
+		t.Errorf("WriteMissingList() =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestFixdatSameDatNames(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{"Zynaps (1987)(Hewson).d64"}, tmpDir)
+
+	first := &dat.Datafile{Games: []dat.Game{
+		{Name: "Zynaps (1987)(Hewson)", Roms: []dat.Rom{{Name: "Zynaps (1987)(Hewson).d64"}}},
+		{Name: "Exolon (1987)(Hewson)", Roms: []dat.Rom{{Name: "Exolon (1987)(Hewson).d64"}}},
+	}}
+	second := &dat.Datafile{Games: []dat.Game{
+		{Name: "Uridium (1986)(Hewson)", Roms: []dat.Rom{{Name: "Uridium (1986)(Hewson).d64"}}},
+	}}
+
+	report, err := Create(tmpDir, "c64").Verify(first, second)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if games := report.Fixdat(first).Games; len(games) != 1 || games[0].Name != "Exolon (1987)(Hewson)" {
+		t.Errorf("Fixdat(first) games = %+v, want only Exolon", games)
+	}
+	if games := report.Fixdat(second).Games; len(games) != 1 || games[0].Name != "Uridium (1986)(Hewson)" {
+		t.Errorf("Fixdat(second) games = %+v, want only Uridium", games)
+	}
+
+	var sb strings.Builder
+	if err := report.WriteMissingList(&sb); err != nil {
+		t.Fatalf("WriteMissingList() error = %v", err)
+	}
+	want := ` (1 missing)
+  Exolon (1987)(Hewson).d64
+
+ (1 missing)
+  Uridium (1986)(Hewson).d64
+`
+	if sb.String() != want {
+		t.Errorf("WriteMissingList() =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,123 @@
+package tosec
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+const unknownDate = "19xx"
+
+// FormatFileName builds a canonical TOSEC file name from a File. It is the
+// inverse of ParseFileName: fields are written in the order required by the
+// naming convention, dump flags are sorted and spacing is normalized.
+func FormatFileName(tf *File) string {
+	var sb strings.Builder
+
+	sb.WriteString(normalizeSpaces(tf.Title))
+	if tf.Demo != "" {
+		sb.WriteString(" (" + tf.Demo + ")")
+	}
+
+	date := tf.Date.String()
+	if date == "" {
+		date = unknownDate
+	}
+	publisher := normalizeSpaces(tf.Publisher)
+	if publisher == "" {
+		publisher = noPublisher
+	}
+	sb.WriteString(" (" + date + ")(" + publisher + ")")
+
+	for _, option := range tf.options() {
+		sb.WriteString("(" + option + ")")
+	}
+	for _, flag := range tf.sortedFlags() {
+		sb.WriteString("[" + flag + "]")
+	}
+
+	if tf.Format != "" {
+		sb.WriteString("." + tf.Format)
+	}
+	return sb.String()
+}
+
+// GameName returns the canonical name of the game the file belongs to: its
+// TOSEC name without media fields and extension, shared by all media of a
+// multi-disk set. Files of other naming schemes keep their own name, also
+// without media fields and extension.
+func (tf *File) GameName() string {
+	if tf.Scheme != "" && tf.Scheme != (TOSECScheme{}).Name() {
+		name := strings.TrimSuffix(tf.FileName, "."+tf.Format)
+		name = reOptions.ReplaceAllStringFunc(name, func(option string) string {
+			if isMedia(strings.TrimSpace(option[1 : len(option)-1])) {
+				return ""
+			}
+			return option
+		})
+		return normalizeSpaces(name)
+	}
+
+	game := *tf
+	game.Media, game.MediaLabel, game.Format = nil, "", ""
+	return FormatFileName(&game)
+}
+
+// MoreInfo returns the bracket flags which are not TOSEC dump flags,
+// e.g. [docs] or [Aka kota].
+func (tf *File) MoreInfo() []string {
+	info := make([]string, 0)
+	for _, flag := range tf.Flags {
+		if _, ok := ParseDumpFlag(flag); !ok {
+			info = append(info, flag)
+		}
+	}
+	return info
+}
+
+// options returns the values of the optional parenthesized fields in the
+// order required by the naming convention.
+func (tf *File) options() []string {
+	var languages string
+	if !tf.LanguageInferred {
+		languages = tf.LanguageCodes()
+	}
+	var media string
+	if tf.Media != nil {
+		media = tf.Media.String()
+	}
+
+	fields := []string{
+		tf.System,
+		tf.Video,
+		tf.CountryCodes(),
+		languages,
+		tf.Copyright,
+		tf.DevStatus,
+		media,
+		normalizeSpaces(tf.MediaLabel),
+	}
+	return slices.DeleteFunc(fields, func(field string) bool { return field == "" })
+}
+
+// sortedFlags returns the dump flags in the TOSEC order followed by the
+// remaining flags in their original order.
+func (tf *File) sortedFlags() []string {
+	dumps := slices.Clone(tf.Dumps)
+	slices.SortStableFunc(dumps, func(a, b DumpFlag) int {
+		return cmp.Compare(a.Kind, b.Kind)
+	})
+
+	flags := make([]string, 0, len(tf.Flags))
+	for _, df := range dumps {
+		flags = append(flags, df.String())
+	}
+	for _, info := range tf.MoreInfo() {
+		flags = append(flags, normalizeSpaces(info))
+	}
+	return flags
+}
+
+func normalizeSpaces(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
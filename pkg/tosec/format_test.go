@@ -0,0 +1,103 @@
+package tosec
+
+import (
+	"testing"
+)
+
+func TestFormatFileNameRoundTrip(t *testing.T) {
+	names := []string{
+		"Zynaps (1987)(Hewson Consultants).zip",
+		"Zynaps (1987)(Hewson Consultants)(EU)(en).zip",
+		"Zynaps (1987)(Hewson Consultants)[a][Aka kota].zip",
+		"Legend of Zelda, The v1.1 (1987)(Nintendo)(JP).nes",
+		"Agony (demo-playable) (1992)(Psygnosis)(A500)(PAL).adf",
+		"Dungeon Master (1988-12-15)(FTL)(ST)(GB)(en-de)(SW)(beta)(Disk 1 of 2)(Program Disk)[cr Fairlight][t +3][a2][docs].st",
+		"Game (198x)(-)(M4)(Side A)[h2 Ikari][tr pl Someone][b][!].d64",
+		"Ghostbusters (1984)(Activision - Ocean)(US)(PD)(proto).prg",
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			tf, err := ParseFileName(name)
+			if err != nil {
+				t.Fatalf("ParseFileName() failed: %v", err)
+			}
+			if got := FormatFileName(tf); got != name {
+				t.Errorf("FormatFileName() = %q, want %q", got, name)
+			}
+		})
+	}
+}
+
+func TestFormatFileNameNormalizes(t *testing.T) {
+	tests := []struct {
+		name string
+		tf   *File
+		want string
+	}{
+		{
+			"flags are sorted",
+			mustParse(t, "Zynaps (1987)(Hewson)[more][a][cr Fairlight].d64"),
+			"Zynaps (1987)(Hewson)[cr Fairlight][a][more].d64",
+		},
+		{
+			"spacing is normalized",
+			mustParse(t, "Zynaps   Two  (1987)(Hewson  Consultants).d64"),
+			"Zynaps Two (1987)(Hewson Consultants).d64",
+		},
+		{
+			"inferred languages are omitted",
+			mustParse(t, "Zynaps (1987)(Hewson)(DE).d64"),
+			"Zynaps (1987)(Hewson)(DE).d64",
+		},
+		{
+			"missing date and publisher use placeholders",
+			&File{Title: "New Dump", Format: "d64"},
+			"New Dump (19xx)(-).d64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatFileName(tt.tf); got != tt.want {
+				t.Errorf("FormatFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParse(t *testing.T, fileName string) *File {
+	t.Helper()
+	tf, err := ParseFileName(fileName)
+	if err != nil {
+		t.Fatalf("ParseFileName(%q) failed: %v", fileName, err)
+	}
+	return tf
+}
+
+func TestGameName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"Zynaps (1987)(Hewson).d64", "Zynaps (1987)(Hewson)"},
+		{"Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64", "Last Ninja, The (1987)(System 3)"},
+		{"Last Ninja, The (1987)(System 3)(Disk 2 of 2)(Game Disk).d64", "Last Ninja, The (1987)(System 3)"},
+		{"Elite (1985)(Firebird)(GB)(Side B)[cr Fairlight].d64", "Elite (1985)(Firebird)(GB)[cr Fairlight]"},
+		{"Super Mario Bros. (World).nes", "Super Mario Bros. (World)"},
+		{"Maniac Mansion (Europe) (Disk 1) (Rev 1).d64", "Maniac Mansion (Europe) (Rev 1)"},
+		{"Mario (U) [!].nes", "Mario (U) [!]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			tf, err := ParseWithSchemes(tt.fileName, DefaultSchemes)
+			if err != nil {
+				t.Fatalf("ParseWithSchemes(%q) failed: %v", tt.fileName, err)
+			}
+			if got := tf.GameName(); got != tt.want {
+				t.Errorf("GameName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package tosec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const regexGoodToolsName = `^(.+?)((?:\s*\([^)]*\)|\s*\[[^\]]*\])+)\.([^.]+)$`
+const regexGoodToolsFlag = `^(!|a|b|f|h|o|p|t|x|cr)(?:(\d+)(.*)|[+_](.*)|)$`
+const regexGoodToolsTranslation = `^T[+-]([A-Z][a-z]{2})([\d.]*)(?:_(.*))?$`
+const regexGoodToolsRevision = `^PRG(\d+)$`
+const regexGoodToolsVersion = `^V(\d+(?:\.\d+)*[a-z]?)$`
+const goodToolsCombinable = "JUE"
+
+// goodToolsRegionless lists the options which may take the place of the
+// country code, as in "Tetris Clone (PD).nes" or "Sachen Game (Unl) [!].nes".
+var goodToolsRegionless = []string{"PD", "Unl", "Hack", "Beta", "Alpha", "Prototype", "Proto"}
+
+var (
+	reGoodToolsName        = regexp.MustCompile(regexGoodToolsName)
+	reGoodToolsFlag        = regexp.MustCompile(regexGoodToolsFlag)
+	reGoodToolsTranslation = regexp.MustCompile(regexGoodToolsTranslation)
+	reGoodToolsRevision    = regexp.MustCompile(regexGoodToolsRevision)
+	reGoodToolsVersion     = regexp.MustCompile(regexGoodToolsVersion)
+)
+
+// GoodToolsCountries maps GoodTools country codes to TOSEC country codes.
+// The Japan, USA and Europe codes may also be combined, e.g. "JU" or "JUE".
+var GoodToolsCountries = map[string][]string{
+	"A":  {"AU"},
+	"As": {"AS"},
+	"B":  {"BR"},
+	"C":  {"CN"},
+	"Ch": {"CN"},
+	"D":  {"NL"},
+	"E":  {"EU"},
+	"F":  {"FR"},
+	"FC": {"CA"},
+	"FN": {"FI"},
+	"G":  {"DE"},
+	"GR": {"GR"},
+	"HK": {"HK"},
+	"I":  {"IT"},
+	"J":  {"JP"},
+	"K":  {"KR"},
+	"NL": {"NL"},
+	"S":  {"ES"},
+	"Sw": {"SE"},
+	"U":  {"US"},
+	"UK": {"GB"},
+	"W":  {"US", "EU", "JP"},
+	"1":  {"JP", "KR"},
+	"4":  {"US", "BR"},
+}
+
+// GoodToolsLanguages maps the language abbreviations used in GoodTools
+// translation flags to ISO 639-1 codes.
+var GoodToolsLanguages = map[string]string{
+	"Ara": "ar", "Bra": "pt", "Cat": "ca", "Chi": "zh", "Dan": "da",
+	"Dut": "nl", "Eng": "en", "Fin": "fi", "Fre": "fr", "Ger": "de",
+	"Gre": "el", "Heb": "he", "Ita": "it", "Jap": "ja", "Kor": "ko",
+	"Nor": "no", "Pol": "pl", "Por": "pt", "Rus": "ru", "Spa": "es",
+	"Swe": "sv", "Tur": "tr",
+}
+
+var goodToolsDumpKinds = map[string]DumpKind{
+	"!":  DumpVerified,
+	"a":  DumpAlternate,
+	"b":  DumpBad,
+	"cr": DumpCracked,
+	"f":  DumpFixed,
+	"h":  DumpHacked,
+	"o":  DumpOverDump,
+	"p":  DumpPirated,
+	"t":  DumpTrained,
+	"x":  DumpBad,
+}
+
+// GoodToolsScheme implements the GoodTools naming convention, e.g.
+// "Super Mario Bros (U) [!].nes" or "Zelda (J) [T+Eng1.0_Zoinkity].n64".
+type GoodToolsScheme struct{}
+
+// Name returns "goodtools".
+func (GoodToolsScheme) Name() string {
+	return "goodtools"
+}
+
+// Detect reports whether the first parenthesized field of the file name is
+// a GoodTools country code, or an option such as (PD) or (Unl) given in
+// its place.
+func (GoodToolsScheme) Detect(fileName string) bool {
+	matches := reGoodToolsName.FindStringSubmatch(fileName)
+	if matches == nil {
+		return false
+	}
+	first := reNoIntroToken.FindStringSubmatch(matches[2])
+	_, ok := parseGoodToolsCountries(first[1])
+	return ok || slices.Contains(goodToolsRegionless, first[1])
+}
+
+// Parse parses a GoodTools file name. Dump codes are converted to their
+// TOSEC equivalents, so File.Flags holds TOSEC style flags. Unknown
+// parenthesized options are rejected.
+func (s GoodToolsScheme) Parse(fileName string) (*File, error) {
+	matches := reGoodToolsName.FindStringSubmatch(fileName)
+	if matches == nil {
+		return nil, errors.New("invalid GoodTools file name format")
+	}
+
+	tf := &File{
+		FileName: fileName,
+		Title:    strings.TrimSpace(matches[1]),
+		Format:   matches[3],
+		Scheme:   s.Name(),
+		Flags:    []string{},
+	}
+
+	tokens := reNoIntroToken.FindAllStringSubmatch(matches[2], -1)
+	if countries, ok := parseGoodToolsCountries(tokens[0][1]); ok {
+		tf.Countries = countries
+		tokens = tokens[1:]
+	} else if !slices.Contains(goodToolsRegionless, tokens[0][1]) {
+		return nil, errors.New("missing GoodTools country code")
+	}
+
+	for _, token := range tokens {
+		if token[0][0] == '[' {
+			tf.Flags = append(tf.Flags, goodToolsFlag(token[2]))
+			continue
+		}
+		if !tf.applyGoodToolsOption(token[1]) {
+			return nil, fmt.Errorf("unknown GoodTools option '(%s)'", token[1])
+		}
+	}
+	tf.Dumps = parseDumpFlags(tf.Flags)
+	tf.inferLanguages()
+
+	return tf, nil
+}
+
+// applyGoodToolsOption sets the field described by a parenthesized option
+// and reports whether the option is known.
+func (tf *File) applyGoodToolsOption(opt string) bool {
+	switch {
+	case reGoodToolsRevision.MatchString(opt):
+		tf.Title += " Rev " + reGoodToolsRevision.FindStringSubmatch(opt)[1]
+	case reGoodToolsVersion.MatchString(opt):
+		tf.Title += " v" + reGoodToolsVersion.FindStringSubmatch(opt)[1]
+	case reMulti.MatchString(opt):
+		tf.MultiLanguage, _ = strconv.Atoi(reMulti.FindStringSubmatch(opt)[1])
+	case opt == "PD":
+		tf.Copyright = opt
+	case opt == "Hack":
+		tf.Flags = append(tf.Flags, DumpHacked.Code())
+	case opt == "Beta" || opt == "Alpha":
+		tf.DevStatus = strings.ToLower(opt)
+	case opt == "Prototype" || opt == "Proto":
+		tf.DevStatus = "proto"
+	case opt == "Unl":
+		// Unlicensed releases have no TOSEC counterpart
+	default:
+		return false
+	}
+	return true
+}
+
+// goodToolsFlag converts a GoodTools dump code into a TOSEC flag. A code
+// must be followed by its number, "+", "_" or nothing; other flags, such as
+// [bios], are returned unchanged. The version of a translation is kept
+// before its group, e.g. [T+Eng1.0_Zoinkity] becomes [tr en v1.0 Zoinkity].
+func goodToolsFlag(flag string) string {
+	if matches := reGoodToolsTranslation.FindStringSubmatch(flag); matches != nil {
+		df := DumpFlag{Kind: DumpTranslated, Language: GoodToolsLanguages[matches[1]], Group: matches[3]}
+		if matches[2] != "" {
+			df.Group = strings.TrimSpace("v" + matches[2] + " " + df.Group)
+		}
+		if df.Language == "" {
+			df.Language = strings.ToLower(matches[1])
+		}
+		return df.String()
+	}
+
+	matches := reGoodToolsFlag.FindStringSubmatch(flag)
+	if matches == nil {
+		return flag
+	}
+	group := strings.TrimSpace(matches[3] + matches[4])
+	if matches[1] == "!" && group != "" {
+		return flag
+	}
+	df := DumpFlag{Kind: goodToolsDumpKinds[matches[1]], Group: group}
+	df.Number, _ = strconv.Atoi(matches[2])
+	return df.String()
+}
+
+func parseGoodToolsCountries(value string) ([]Country, bool) {
+	codes, ok := GoodToolsCountries[value]
+	if !ok {
+		// Combined single letter codes such as "JU" or "JUE"
+		for _, r := range value {
+			if !strings.ContainsRune(goodToolsCombinable, r) {
+				return nil, false
+			}
+			codes = append(codes, GoodToolsCountries[string(r)]...)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, false
+	}
+
+	countries := make([]Country, len(codes))
+	for i, code := range codes {
+		countries[i] = Countries[code]
+	}
+	return countries, true
+}
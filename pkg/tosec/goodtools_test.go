@@ -0,0 +1,106 @@
+package tosec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoodToolsParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileName      string
+		wantTitle     string
+		wantCountries string
+		wantFlags     []string
+	}{
+		{"verified dump", "Super Mario Bros (U) [!].nes", "Super Mario Bros", "US", []string{"!"}},
+		{"translation", "Zelda (J) [T+Eng1.0_Zoinkity].n64", "Zelda", "JP", []string{"tr en v1.0 Zoinkity"}},
+		{"translation without version", "Zelda (J) [T-Ger_Mumm].n64", "Zelda", "JP", []string{"tr de Mumm"}},
+		{"translation without group", "Zelda (J) [T+Fre1.2].n64", "Zelda", "JP", []string{"tr fr v1.2"}},
+		{"numbered bad dump", "Contra (U) [b1].nes", "Contra", "US", []string{"b1"}},
+		{"numbered over dump", "Contra (E) [o2].nes", "Contra", "EU", []string{"o2"}},
+		{"program revision", "Mega Man (U) (PRG1).nes", "Mega Man Rev 1", "US", []string{}},
+		{"version", "F-Zero (E) (V1.1) [!].sfc", "F-Zero v1.1", "EU", []string{"!"}},
+		{"combined countries", "Tetris (JU) [h1C].gb", "Tetris", "JP-US", []string{"h1 C"}},
+		{"world", "Pong (W).gb", "Pong", "US-EU-JP", []string{}},
+		{"pending flag is kept", "Pong (U) [!p].gb", "Pong", "US", []string{"!p"}},
+		{"word starting with a code is kept", "Mario (U) [bios].nes", "Mario", "US", []string{"bios"}},
+		{"code with group", "Contra (U) [h_Hacker].nes", "Contra", "US", []string{"h Hacker"}},
+		{"public domain without country", "Game (PD).nes", "Game", "", []string{}},
+		{"unlicensed without country", "Game (Unl) [!].nes", "Game", "", []string{"!"}},
+		{"unlicensed after country", "Game (As) (Unl).nes", "Game", "AS", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := GoodToolsScheme{}
+			if !scheme.Detect(tt.fileName) {
+				t.Fatalf("Detect(%q) = false, want true", tt.fileName)
+			}
+			tf, err := scheme.Parse(tt.fileName)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.fileName, err)
+			}
+			if tf.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", tf.Title, tt.wantTitle)
+			}
+			if tf.CountryCodes() != tt.wantCountries {
+				t.Errorf("CountryCodes() = %q, want %q", tf.CountryCodes(), tt.wantCountries)
+			}
+			if !reflect.DeepEqual(tf.Flags, tt.wantFlags) {
+				t.Errorf("Flags = %v, want %v", tf.Flags, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestGoodToolsDumpInfo(t *testing.T) {
+	tf, err := GoodToolsScheme{}.Parse("Zelda (J) (Hack) [T+Eng1.0_Zoinkity][b1].n64")
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	translation, ok := tf.Dump(DumpTranslated)
+	if !ok || translation.Language != "en" || translation.Group != "v1.0 Zoinkity" {
+		t.Errorf("Expected English translation by Zoinkity, got %+v", translation)
+	}
+	if !tf.HasDump(DumpHacked) || !tf.HasDump(DumpBad) {
+		t.Errorf("Expected hacked and bad dump flags, got %+v", tf.Dumps)
+	}
+	formatted := FormatFileName(tf)
+	if formatted != "Zelda (19xx)(-)(JP)[h][tr en v1.0 Zoinkity][b1].n64" {
+		t.Errorf("FormatFileName() = %q", formatted)
+	}
+	reparsed, err := ParseFileName(formatted)
+	if err != nil {
+		t.Fatalf("ParseFileName(%q) failed: %v", formatted, err)
+	}
+	if got, _ := reparsed.Dump(DumpTranslated); got.String() != translation.String() {
+		t.Errorf("translation after formatting = %q, want %q", got, translation)
+	}
+}
+
+func TestGoodToolsUnknownOption(t *testing.T) {
+	if _, err := (GoodToolsScheme{}).Parse("Contra (U) (Sachen).nes"); err == nil {
+		t.Error("Parse() of an unknown option succeeded, want an error")
+	}
+}
+
+func TestDetectGoodToolsScheme(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"Super Mario Bros (U) [!].nes", "goodtools"},
+		{"Game (Unl) [!].nes", "goodtools"},
+		{"Super Mario Bros. (USA).nes", "no-intro"},
+		{"Super Mario Bros (1985)(Nintendo)(US).nes", "tosec"},
+	}
+
+	for _, tt := range tests {
+		scheme, ok := DetectScheme(tt.fileName, DefaultSchemes)
+		if !ok || scheme.Name() != tt.want {
+			t.Errorf("DetectScheme(%q) = %v, want %q", tt.fileName, scheme, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package tosec
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LanguageCodes returns the language field as it appears in the file name,
+// e.g. "en-de" or "M4". Inferred languages are included.
+func (tf *File) LanguageCodes() string {
+	if tf.MultiLanguage > 0 {
+		return fmt.Sprintf("M%d", tf.MultiLanguage)
+	}
+	return strings.Join(tf.Languages, "-")
+}
+
+// HasLanguage reports whether the file is known to contain the given language.
+func (tf *File) HasLanguage(code string) bool {
+	return slices.Contains(tf.Languages, code)
+}
+
+func (tf *File) hasLanguageField() bool {
+	return tf.Languages != nil || tf.MultiLanguage > 0
+}
+
+// inferLanguages fills in the default languages of the file's countries
+// when the language field is missing, as the TOSEC naming convention
+// only requires the language when it differs from the country's.
+func (tf *File) inferLanguages() {
+	if tf.hasLanguageField() {
+		return
+	}
+	var languages []string
+	for _, country := range tf.Countries {
+		if country.Language != "" && !slices.Contains(languages, country.Language) {
+			languages = append(languages, country.Language)
+		}
+	}
+	if len(languages) > 0 {
+		tf.Languages = languages
+		tf.LanguageInferred = true
+	}
+}
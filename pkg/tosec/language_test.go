@@ -0,0 +1,47 @@
+package tosec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLanguages(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileName      string
+		wantLanguages []string
+		wantMulti     int
+		wantInferred  bool
+		wantCodes     string
+	}{
+		{"single language", "Game (1990)(Pub)(DE)(en).d64", []string{"en"}, 0, false, "en"},
+		{"two languages", "Game (1990)(Pub)(en-de).d64", []string{"en", "de"}, 0, false, "en-de"},
+		{"three languages", "Game (1990)(Pub)(en-de-fr).d64", []string{"en", "de", "fr"}, 0, false, "en-de-fr"},
+		{"multi language marker", "Game (1990)(Pub)(EU)(M4).d64", nil, 4, false, "M4"},
+		{"inferred from country", "Game (1990)(Pub)(DE).d64", []string{"de"}, 0, true, "de"},
+		{"inferred from several countries", "Game (1990)(Pub)(US-GB-PL).d64", []string{"en", "pl"}, 0, true, "en-pl"},
+		{"no country and no language", "Game (1990)(Pub).d64", nil, 0, false, ""},
+		{"unknown language code", "Game (1990)(Pub)(xx).d64", nil, 0, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf, err := ParseFileName(tt.fileName)
+			if err != nil {
+				t.Fatalf("ParseFileName() failed: %v", err)
+			}
+			if !reflect.DeepEqual(tf.Languages, tt.wantLanguages) {
+				t.Errorf("Languages = %v, want %v", tf.Languages, tt.wantLanguages)
+			}
+			if tf.MultiLanguage != tt.wantMulti {
+				t.Errorf("MultiLanguage = %d, want %d", tf.MultiLanguage, tt.wantMulti)
+			}
+			if tf.LanguageInferred != tt.wantInferred {
+				t.Errorf("LanguageInferred = %v, want %v", tf.LanguageInferred, tt.wantInferred)
+			}
+			if got := tf.LanguageCodes(); got != tt.wantCodes {
+				t.Errorf("LanguageCodes() = %q, want %q", got, tt.wantCodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package tosec
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const regexMissingPublisher = `^(.*? \([0-9x-]+\))([^(]*\.[^.]+)$`
+const regexMissingSpace = `^(.*?\S)(\([0-9x-]+\)\()`
+const regexOptionAfterFlag = `\][^\[]*\(`
+const regexBadSpacing = `^\s|  |[)\]] +[(\[]|\s$`
+const regexLanguageLike = `^[a-z]{2}(-[a-z]{2})*$`
+const regexCountryLike = `^[A-Z]{2}(-[A-Z]{2})*$`
+const illegalCharacters = `/\:*?"<>|`
+
+var (
+	reMissingPublisher = regexp.MustCompile(regexMissingPublisher)
+	reMissingSpace     = regexp.MustCompile(regexMissingSpace)
+	reOptionAfterFlag  = regexp.MustCompile(regexOptionAfterFlag)
+	reBadSpacing       = regexp.MustCompile(regexBadSpacing)
+	reLanguageLike     = regexp.MustCompile(regexLanguageLike)
+	reCountryLike      = regexp.MustCompile(regexCountryLike)
+)
+
+// Lint rule identifiers.
+const (
+	RuleInvalidFormat     = "invalid-format"
+	RuleMissingPublisher  = "missing-publisher"
+	RuleMissingSpace      = "missing-space"
+	RuleIllegalCharacters = "illegal-characters"
+	RuleBadDate           = "bad-date"
+	RuleFlagOrder         = "flag-order"
+	RuleFieldOrder        = "field-order"
+	RuleUnknownLanguage   = "unknown-language"
+	RuleUnknownCountry    = "unknown-country"
+	RuleUnknownOption     = "unknown-option"
+	RuleSpacing           = "spacing"
+	RuleNonCanonical      = "non-canonical"
+)
+
+// Severity describes how serious a naming violation is.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String returns the lower case name of the severity.
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Violation describes a single breach of the TOSEC naming convention.
+type Violation struct {
+	Rule       string   `json:"rule"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// LintResult holds the violations found for a single file.
+type LintResult struct {
+	Path       string      `json:"path"`
+	FileName   string      `json:"file"`
+	Violations []Violation `json:"violations"`
+}
+
+// HasErrors reports whether any of the violations has error severity.
+func (lr LintResult) HasErrors() bool {
+	return slices.ContainsFunc(lr.Violations, func(v Violation) bool {
+		return v.Severity == SeverityError
+	})
+}
+
+// LintFileName checks a file name against the TOSEC naming convention and
+// returns the violations found. An empty slice means the name is compliant.
+func LintFileName(fileName string) []Violation {
+	violations := make([]Violation, 0)
+
+	if strings.ContainsAny(fileName, illegalCharacters) || !isPrintableASCII(fileName) {
+		violations = append(violations, Violation{
+			Rule:       RuleIllegalCharacters,
+			Severity:   SeverityError,
+			Message:    "file name contains characters which are not allowed",
+			Suggestion: "use printable ASCII characters only, without " + illegalCharacters,
+		})
+	}
+
+	tf, err := ParseFileName(fileName)
+	if err != nil {
+		return append(violations, lintUnparsable(fileName, err))
+	}
+
+	violations = append(violations, lintOptions(tf)...)
+	violations = append(violations, lintFlags(tf)...)
+
+	canonical := FormatFileName(tf)
+	if hasBadSpacing(tf) {
+		violations = append(violations, Violation{
+			Rule:       RuleSpacing,
+			Severity:   SeverityWarning,
+			Message:    "file name contains superfluous spaces",
+			Suggestion: fmt.Sprintf("rename to '%s'", canonical),
+		})
+	}
+	if len(violations) == 0 && canonical != fileName {
+		violations = append(violations, Violation{
+			Rule:       RuleNonCanonical,
+			Severity:   SeverityInfo,
+			Message:    "file name differs from its canonical form",
+			Suggestion: fmt.Sprintf("rename to '%s'", canonical),
+		})
+	}
+
+	return violations
+}
+
+// Lint checks every file in the folder against the TOSEC naming convention.
+// Only files with at least one violation are returned.
+func (tosecFolder *Folder) Lint() ([]LintResult, error) {
+	entries, errCh := tosecFolder.GetFileTree()
+	results := make([]LintResult, 0)
+
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		violations := LintFileName(entry.Name)
+		if len(violations) == 0 {
+			continue
+		}
+		results = append(results, LintResult{
+			Path:       filepath.Join(entry.Folder, entry.Name),
+			FileName:   entry.Name,
+			Violations: violations,
+		})
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func lintUnparsable(fileName string, err error) Violation {
+	switch {
+	case errors.Is(err, ErrInvalidDate):
+		return Violation{
+			Rule:       RuleBadDate,
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Suggestion: "use YYYY, YYYY-MM or YYYY-MM-DD and replace unknown digits with 'x', e.g. 198x",
+		}
+	case reMissingSpace.MatchString(fileName):
+		return Violation{
+			Rule:       RuleMissingSpace,
+			Severity:   SeverityError,
+			Message:    "title is not separated from the date by a space",
+			Suggestion: fmt.Sprintf("rename to '%s'", reMissingSpace.ReplaceAllString(fileName, "$1 $2")),
+		}
+	case reMissingPublisher.MatchString(fileName):
+		return Violation{
+			Rule:       RuleMissingPublisher,
+			Severity:   SeverityError,
+			Message:    "publisher field is missing",
+			Suggestion: fmt.Sprintf("rename to '%s'", reMissingPublisher.ReplaceAllString(fileName, "$1(-)$2")),
+		}
+	}
+	return Violation{
+		Rule:       RuleInvalidFormat,
+		Severity:   SeverityError,
+		Message:    err.Error(),
+		Suggestion: "name the file 'Title (Date)(Publisher).ext'",
+	}
+}
+
+func lintOptions(tf *File) []Violation {
+	violations := make([]Violation, 0)
+	rest := tf.extractRestPartOfName()
+
+	if reOptionAfterFlag.MatchString(rest) {
+		violations = append(violations, Violation{
+			Rule:       RuleFieldOrder,
+			Severity:   SeverityWarning,
+			Message:    "parenthesized fields must come before bracketed flags",
+			Suggestion: fmt.Sprintf("rename to '%s'", FormatFileName(tf)),
+		})
+	}
+
+	scratch := &File{}
+	last, prev := fieldNone, fieldNone
+	for _, opt := range extractValues(reOptions.FindAllStringSubmatch(rest, -1)) {
+		field := scratch.applyOption(opt, prev)
+		prev = field
+		if field == fieldNone {
+			violations = append(violations, lintUnknownOption(opt))
+			continue
+		}
+		if field < last {
+			violations = append(violations, Violation{
+				Rule:       RuleFieldOrder,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("%s field '%s' must come before the %s field", field, opt, last),
+				Suggestion: fmt.Sprintf("rename to '%s'", FormatFileName(tf)),
+			})
+		}
+		last = max(last, field)
+	}
+
+	return violations
+}
+
+func lintUnknownOption(opt string) Violation {
+	switch {
+	case reLanguageLike.MatchString(opt):
+		return Violation{
+			Rule:       RuleUnknownLanguage,
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown language code '%s'", opt),
+			Suggestion: "use ISO 639-1 language codes, e.g. (en) or (en-de)",
+		}
+	case reCountryLike.MatchString(opt):
+		return Violation{
+			Rule:       RuleUnknownCountry,
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown country code '%s'", opt),
+			Suggestion: "use ISO 3166-1 country codes, e.g. (US) or (GB-FR)",
+		}
+	}
+	return Violation{
+		Rule:       RuleUnknownOption,
+		Severity:   SeverityWarning,
+		Message:    fmt.Sprintf("unrecognized field '(%s)'", opt),
+		Suggestion: fmt.Sprintf("move it to a more info flag, e.g. [%s]", opt),
+	}
+}
+
+func lintFlags(tf *File) []Violation {
+	canonical := tf.sortedFlags()
+	flags := make([]string, len(tf.Flags))
+	for i, flag := range tf.Flags {
+		flags[i] = normalizeSpaces(flag)
+		if df, ok := ParseDumpFlag(flag); ok {
+			flags[i] = df.String()
+		}
+	}
+
+	if slices.Equal(flags, canonical) {
+		return []Violation{}
+	}
+	return []Violation{{
+		Rule:       RuleFlagOrder,
+		Severity:   SeverityWarning,
+		Message:    fmt.Sprintf("flags must be ordered as [%s]", strings.Join(canonical, "][")),
+		Suggestion: fmt.Sprintf("rename to '%s'", FormatFileName(tf)),
+	}}
+}
+
+func hasBadSpacing(tf *File) bool {
+	return strings.Contains(tf.Title, "  ") ||
+		strings.Contains(tf.Publisher, "  ") ||
+		reBadSpacing.MatchString(tf.extractRestPartOfName())
+}
+
+func isPrintableASCII(value string) bool {
+	for _, r := range value {
+		if r < ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package tosec
+
+import (
+	"os"
+	"testing"
+
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestLintFileName(t *testing.T) {
+	tests := []struct {
+		name           string
+		fileName       string
+		wantRules      []string
+		wantSuggestion string
+	}{
+		{"compliant name", "Zynaps (1987)(Hewson Consultants)(GB)(en)[cr Fairlight][a].d64", nil, ""},
+		{"missing publisher", "Zynaps (1987)[a].d64", []string{RuleMissingPublisher}, "rename to 'Zynaps (1987)(-)[a].d64'"},
+		{"missing space", "Zynaps(1987)(Hewson).d64", []string{RuleMissingSpace}, "rename to 'Zynaps (1987)(Hewson).d64'"},
+		{"bad date", "Zynaps (1987-13)(Hewson).d64", []string{RuleBadDate}, ""},
+		{"not a tosec name", "zynaps.d64", []string{RuleInvalidFormat}, ""},
+		{"flags out of order", "Zynaps (1987)(Hewson)[a][cr Fairlight].d64", []string{RuleFlagOrder}, "rename to 'Zynaps (1987)(Hewson)[cr Fairlight][a].d64'"},
+		{"more info before dump flag", "Zynaps (1987)(Hewson)[docs][!].d64", []string{RuleFlagOrder}, "rename to 'Zynaps (1987)(Hewson)[!][docs].d64'"},
+		{"option after flag", "Zynaps (1987)(Hewson)[a](Disk 1 of 2).d64", []string{RuleFieldOrder}, "rename to 'Zynaps (1987)(Hewson)(Disk 1 of 2)[a].d64'"},
+		{"fields out of order", "Zynaps (1987)(Hewson)(en)(DE).d64", []string{RuleFieldOrder}, "rename to 'Zynaps (1987)(Hewson)(DE)(en).d64'"},
+		{"unknown language", "Zynaps (1987)(Hewson)(xx).d64", []string{RuleUnknownLanguage}, ""},
+		{"unknown country", "Zynaps (1987)(Hewson)(XX).d64", []string{RuleUnknownCountry}, ""},
+		{"unknown option", "Zynaps (1987)(Hewson)(Bonus).d64", []string{RuleUnknownOption}, ""},
+		{"media label", "Zynaps (1987)(Hewson)(Disk 1 of 2)(Program Disk).d64", nil, ""},
+		{"second media label", "Zynaps (1987)(Hewson)(Disk 1 of 2)(Program Disk)(Bonus).d64", []string{RuleUnknownOption}, ""},
+		{"media label after other field", "Zynaps (1987)(Hewson)(Disk 1 of 2)(PD)(Program Disk).d64", []string{RuleFieldOrder, RuleUnknownOption}, ""},
+		{"separate media side", "Zynaps (1987)(Hewson)(Disk 1 of 2)(Side A).d64", []string{RuleNonCanonical}, "rename to 'Zynaps (1987)(Hewson)(Disk 1 of 2 Side A).d64'"},
+		{"illegal characters", "Zynaps: Revenge (1987)(Hewson).d64", []string{RuleIllegalCharacters}, ""},
+		{"superfluous spaces", "Zynaps (1987)(Hewson) [a].d64", []string{RuleSpacing}, "rename to 'Zynaps (1987)(Hewson)[a].d64'"},
+		{"non canonical", "Zynaps (1987)(Hewson)(Disk 01 of 2).d64", []string{RuleNonCanonical}, "rename to 'Zynaps (1987)(Hewson)(Disk 1 of 2).d64'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := LintFileName(tt.fileName)
+
+			var rules []string
+			for _, v := range violations {
+				rules = append(rules, v.Rule)
+			}
+			if len(rules) != len(tt.wantRules) {
+				t.Fatalf("LintFileName(%q) rules = %v, want %v", tt.fileName, rules, tt.wantRules)
+			}
+			for i := range rules {
+				if rules[i] != tt.wantRules[i] {
+					t.Errorf("LintFileName(%q) rules = %v, want %v", tt.fileName, rules, tt.wantRules)
+				}
+			}
+			if tt.wantSuggestion != "" && violations[0].Suggestion != tt.wantSuggestion {
+				t.Errorf("Suggestion = %q, want %q", violations[0].Suggestion, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestFolderLint(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testFiles := []string{
+		"Zynaps (1987)(Hewson).d64",
+		"Zynaps (1987).d64",
+		"subdir/Uridium (1986)(Hewson)[a][cr].d64",
+	}
+	testutils.CreateTestFiles(t, testFiles, tmpDir)
+
+	results, err := Create(tmpDir, "c64").Lint()
+	if err != nil {
+		t.Fatalf("Lint() failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+	}
+	if !results[0].HasErrors() || results[0].Violations[0].Rule != RuleMissingPublisher {
+		t.Errorf("Expected missing publisher error, got %+v", results[0])
+	}
+	if results[1].HasErrors() || results[1].Path != "subdir/Uridium (1986)(Hewson)[a][cr].d64" {
+		t.Errorf("Expected flag order warning in subdir, got %+v", results[1])
+	}
+}
@@ -0,0 +1,61 @@
+package tosec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const regexMedia = `^(?:(Disc|Disk|File|Part|Tape) (\d+)(?: of (\d+))?)?\s*(?:Side ([A-Z]))?$`
+
+var reMedia = regexp.MustCompile(regexMedia)
+
+// Media represents the TOSEC media field, e.g. "Disk 1 of 2" or "Side A".
+type Media struct {
+	Type   string // Disc, Disk, File, Part, Side or Tape
+	Number int    // Index of the medium, 0 when not numbered
+	Total  int    // Total number of media in the set, 0 when unknown
+	Side   string // Side letter, empty when not set
+}
+
+// ParseMedia parses the content of a TOSEC media field.
+func ParseMedia(value string) (*Media, bool) {
+	matches := reMedia.FindStringSubmatch(value)
+	if matches == nil || (matches[1] == "" && matches[4] == "") {
+		return nil, false
+	}
+
+	media := &Media{Type: matches[1], Side: matches[4]}
+	if media.Type == "" {
+		media.Type = "Side"
+	}
+	media.Number, _ = strconv.Atoi(matches[2])
+	media.Total, _ = strconv.Atoi(matches[3])
+	return media, true
+}
+
+// String formats the media back into its TOSEC representation.
+func (m Media) String() string {
+	parts := make([]string, 0, 3)
+	if m.Number > 0 {
+		parts = append(parts, fmt.Sprintf("%s %d", m.Type, m.Number))
+	}
+	if m.Total > 0 {
+		parts = append(parts, fmt.Sprintf("of %d", m.Total))
+	}
+	if m.Side != "" {
+		parts = append(parts, "Side "+m.Side)
+	}
+	return strings.Join(parts, " ")
+}
+
+// IsMultiMedia reports whether the media is a part of a set of several media.
+func (m Media) IsMultiMedia() bool {
+	return m.Total > 1 || m.Side != ""
+}
+
+func isMedia(value string) bool {
+	_, ok := ParseMedia(value)
+	return ok
+}
@@ -0,0 +1,89 @@
+package tosec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMedia(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   *Media
+		wantOk bool
+	}{
+		{"Disk 1 of 2", &Media{Type: "Disk", Number: 1, Total: 2}, true},
+		{"Disk 3", &Media{Type: "Disk", Number: 3}, true},
+		{"Tape 2 of 3", &Media{Type: "Tape", Number: 2, Total: 3}, true},
+		{"Part 1 of 4", &Media{Type: "Part", Number: 1, Total: 4}, true},
+		{"Side A", &Media{Type: "Side", Side: "A"}, true},
+		{"Disk 1 of 2 Side B", &Media{Type: "Disk", Number: 1, Total: 2, Side: "B"}, true},
+		{"Save Disk", nil, false},
+		{"", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseMedia(tt.value)
+			if ok != tt.wantOk {
+				t.Fatalf("ParseMedia(%q) ok = %v, want %v", tt.value, ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMedia(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+			if ok && got.String() != tt.value {
+				t.Errorf("Media.String() = %q, want %q", got.String(), tt.value)
+			}
+		})
+	}
+}
+
+func TestParseStatusAndMediaFields(t *testing.T) {
+	tf, err := ParseFileName("Dungeon Master (1988)(FTL)(EU)(SW)(beta)(Disk 1 of 2)(Program Disk)[a].st")
+	if err != nil {
+		t.Fatalf("ParseFileName() failed: %v", err)
+	}
+
+	if tf.Copyright != "SW" {
+		t.Errorf("Copyright = %q, want %q", tf.Copyright, "SW")
+	}
+	if tf.DevStatus != "beta" {
+		t.Errorf("DevStatus = %q, want %q", tf.DevStatus, "beta")
+	}
+	if tf.Media == nil || tf.Media.Number != 1 || tf.Media.Total != 2 {
+		t.Errorf("Media = %+v, want Disk 1 of 2", tf.Media)
+	}
+	if tf.MediaLabel != "Program Disk" {
+		t.Errorf("MediaLabel = %q, want %q", tf.MediaLabel, "Program Disk")
+	}
+	if !tf.Media.IsMultiMedia() {
+		t.Errorf("IsMultiMedia() = false, want true")
+	}
+}
+
+func TestParseMediaSideAndLabel(t *testing.T) {
+	tests := []struct {
+		fileName  string
+		wantMedia string
+		wantLabel string
+	}{
+		{"Zynaps (1987)(Hewson)(Disk 1 of 2)(Side A).d64", "Disk 1 of 2 Side A", ""},
+		{"Zynaps (1987)(Hewson)(Disk 1 of 2)(Side A)(Program Disk).d64", "Disk 1 of 2 Side A", "Program Disk"},
+		{"Zynaps (1987)(Hewson)(Disk 1 of 2 Side A)(Side B).d64", "Disk 1 of 2 Side A", ""},
+		{"Zynaps (1987)(Hewson)(Disk 1 of 2)(PD)(Program Disk).d64", "Disk 1 of 2", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			tf, err := ParseFileName(tt.fileName)
+			if err != nil {
+				t.Fatalf("ParseFileName() failed: %v", err)
+			}
+			if tf.Media == nil || tf.Media.String() != tt.wantMedia {
+				t.Errorf("Media = %+v, want %s", tf.Media, tt.wantMedia)
+			}
+			if tf.MediaLabel != tt.wantLabel {
+				t.Errorf("MediaLabel = %q, want %q", tf.MediaLabel, tt.wantLabel)
+			}
+		})
+	}
+}
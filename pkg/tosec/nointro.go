@@ -0,0 +1,149 @@
+package tosec
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const regexNoIntroName = `^(.+?)((?: \([^)]*\)| \[[^\]]*\])+)\.([^.]+)$`
+const regexNoIntroToken = `\(([^)]*)\)|\[([^\]]*)\]`
+const regexNoIntroLanguages = `^[A-Z][a-z](?:-[A-Z][a-z])?(?:,[A-Z][a-z](?:-[A-Z][a-z])?)*$`
+const regexNoIntroVersion = `^(?:v\d+(?:\.\d+)*[a-z]?|Rev \d+(?:\.\d+)*|Rev [A-Z])$`
+const regexNoIntroStatus = `^(Alpha|Beta|Proto|Demo|Sample)(?: \d+)?$`
+
+var (
+	reNoIntroName      = regexp.MustCompile(regexNoIntroName)
+	reNoIntroToken     = regexp.MustCompile(regexNoIntroToken)
+	reNoIntroLanguages = regexp.MustCompile(regexNoIntroLanguages)
+	reNoIntroVersion   = regexp.MustCompile(regexNoIntroVersion)
+	reNoIntroStatus    = regexp.MustCompile(regexNoIntroStatus)
+)
+
+// NoIntroRegions maps No-Intro region names to TOSEC country codes.
+// "World" maps to no country, as TOSEC leaves the country of worldwide
+// releases out.
+var NoIntroRegions = map[string][]string{
+	"World":       {},
+	"USA":         {"US"},
+	"Europe":      {"EU"},
+	"Japan":       {"JP"},
+	"Asia":        {"AS"},
+	"Australia":   {"AU"},
+	"Brazil":      {"BR"},
+	"Canada":      {"CA"},
+	"China":       {"CN"},
+	"Denmark":     {"DK"},
+	"Finland":     {"FI"},
+	"France":      {"FR"},
+	"Germany":     {"DE"},
+	"Greece":      {"GR"},
+	"Hong Kong":   {"HK"},
+	"India":       {"IN"},
+	"Italy":       {"IT"},
+	"Korea":       {"KR"},
+	"Mexico":      {"MX"},
+	"Netherlands": {"NL"},
+	"Norway":      {"NO"},
+	"Poland":      {"PL"},
+	"Portugal":    {"PT"},
+	"Russia":      {"RU"},
+	"Spain":       {"ES"},
+	"Sweden":      {"SE"},
+	"Taiwan":      {"TW"},
+	"UK":          {"GB"},
+}
+
+// NoIntroScheme implements the No-Intro naming convention, e.g.
+// "Super Mario Bros. (World) (Rev 1).nes".
+type NoIntroScheme struct{}
+
+// Name returns "no-intro".
+func (NoIntroScheme) Name() string {
+	return "no-intro"
+}
+
+// Detect reports whether the first parenthesized field of the file name is
+// a No-Intro region list.
+func (NoIntroScheme) Detect(fileName string) bool {
+	matches := reNoIntroName.FindStringSubmatch(fileName)
+	if matches == nil {
+		return false
+	}
+	first := reNoIntroToken.FindStringSubmatch(matches[2])
+	_, ok := parseNoIntroRegions(first[1])
+	return ok
+}
+
+// Parse parses a No-Intro file name. Versions are appended to the title,
+// as TOSEC does, so that File.Version works for both schemes. Options
+// without a TOSEC field are left out.
+func (s NoIntroScheme) Parse(fileName string) (*File, error) {
+	matches := reNoIntroName.FindStringSubmatch(fileName)
+	if matches == nil {
+		return nil, errors.New("invalid No-Intro file name format")
+	}
+
+	tf := &File{
+		FileName: fileName,
+		Title:    strings.TrimSpace(matches[1]),
+		Format:   matches[3],
+		Scheme:   s.Name(),
+		Flags:    []string{},
+	}
+
+	tokens := reNoIntroToken.FindAllStringSubmatch(matches[2], -1)
+	countries, ok := parseNoIntroRegions(tokens[0][1])
+	if !ok {
+		return nil, errors.New("missing No-Intro region")
+	}
+	tf.Countries = countries
+
+	for _, token := range tokens[1:] {
+		if token[0][0] == '[' {
+			tf.Flags = append(tf.Flags, token[2])
+			continue
+		}
+		tf.applyNoIntroOption(token[1])
+	}
+	tf.Dumps = parseDumpFlags(tf.Flags)
+	tf.inferLanguages()
+
+	return tf, nil
+}
+
+func (tf *File) applyNoIntroOption(opt string) {
+	switch {
+	case tf.Languages == nil && reNoIntroLanguages.MatchString(opt):
+		tf.Languages = strings.Split(strings.ToLower(opt), ",")
+	case reNoIntroVersion.MatchString(opt):
+		tf.Title += " " + opt
+	case tf.DevStatus == "" && tf.Demo == "" && reNoIntroStatus.MatchString(opt):
+		status := strings.ToLower(reNoIntroStatus.FindStringSubmatch(opt)[1])
+		if status == "demo" || status == "sample" {
+			tf.Demo = "demo"
+		} else {
+			tf.DevStatus = status
+		}
+	case tf.Media == nil && isMedia(opt):
+		tf.Media, _ = ParseMedia(opt)
+	case opt == "Pirate":
+		tf.Flags = append(tf.Flags, DumpPirated.Code())
+	default:
+		// Options such as (Unl) have no TOSEC counterpart and are left out
+	}
+}
+
+func parseNoIntroRegions(value string) ([]Country, bool) {
+	var countries []Country
+	for _, region := range strings.Split(value, ", ") {
+		codes, ok := NoIntroRegions[region]
+		if !ok {
+			return nil, false
+		}
+		for _, code := range codes {
+			countries = append(countries, Countries[code])
+		}
+	}
+	return countries, true
+}
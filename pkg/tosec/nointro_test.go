@@ -0,0 +1,122 @@
+package tosec
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestNoIntroParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileName      string
+		wantTitle     string
+		wantCountries string
+		wantLanguages []string
+		wantDevStatus string
+		wantFlags     []string
+	}{
+		{"world release with revision", "Super Mario Bros. (World) (Rev 1).nes", "Super Mario Bros. Rev 1", "", nil, "", []string{}},
+		{"multiple regions and languages", "Tetris (USA, Europe) (En,Fr,De).gb", "Tetris", "US-EU", []string{"en", "fr", "de"}, "", []string{}},
+		{"prototype", "Zelda (Japan) (Proto).sfc", "Zelda", "JP", []string{"ja"}, "proto", []string{}},
+		{"bad dump and unlicensed", "Bomb Jack (Europe) (Unl) [b].nes", "Bomb Jack", "EU", nil, "", []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := NoIntroScheme{}
+			if !scheme.Detect(tt.fileName) {
+				t.Fatalf("Detect(%q) = false, want true", tt.fileName)
+			}
+			tf, err := scheme.Parse(tt.fileName)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.fileName, err)
+			}
+			if tf.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", tf.Title, tt.wantTitle)
+			}
+			if tf.CountryCodes() != tt.wantCountries {
+				t.Errorf("CountryCodes() = %q, want %q", tf.CountryCodes(), tt.wantCountries)
+			}
+			if !reflect.DeepEqual(tf.Languages, tt.wantLanguages) {
+				t.Errorf("Languages = %v, want %v", tf.Languages, tt.wantLanguages)
+			}
+			if tf.DevStatus != tt.wantDevStatus {
+				t.Errorf("DevStatus = %q, want %q", tf.DevStatus, tt.wantDevStatus)
+			}
+			if !reflect.DeepEqual(tf.Flags, tt.wantFlags) {
+				t.Errorf("Flags = %v, want %v", tf.Flags, tt.wantFlags)
+			}
+			if tf.Scheme != "no-intro" {
+				t.Errorf("Scheme = %q, want no-intro", tf.Scheme)
+			}
+		})
+	}
+}
+
+func TestDetectScheme(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"Zynaps (1987)(Hewson Consultants)(GB).d64", "tosec"},
+		{"Super Mario Bros. (World).nes", "no-intro"},
+		{"Tetris (USA, Europe) (En,Fr,De).gb", "no-intro"},
+		{"random_file.nes", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			scheme, ok := DetectScheme(tt.fileName, DefaultSchemes)
+			got := ""
+			if ok {
+				got = scheme.Name()
+			}
+			if got != tt.want {
+				t.Errorf("DetectScheme(%q) = %q, want %q", tt.fileName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWithSchemesErrors(t *testing.T) {
+	if _, err := ParseWithSchemes("Zynaps (1987-13)(Hewson).d64", DefaultSchemes); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("ParseWithSchemes() error = %v, want ErrInvalidDate", err)
+	}
+	if _, err := ParseWithSchemes("random_file.nes", DefaultSchemes); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("ParseWithSchemes() error = %v, want ErrUnknownScheme", err)
+	}
+}
+
+func TestGetFilesWithSchemes(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testFiles := []string{
+		"Super Mario Bros. (World).nes",
+		"Zelda (1986)(Nintendo)(JP).nes",
+		"unknown.nes",
+	}
+	testutils.CreateTestFiles(t, testFiles, tmpDir)
+
+	folder := Create(tmpDir, "nes")
+	files, err := folder.GetFiles()
+	if err != nil {
+		t.Fatalf("GetFiles() failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Scheme != "no-intro" || files[1].Scheme != "tosec" {
+		t.Errorf("Expected a No-Intro and a TOSEC file, got %+v", files)
+	}
+
+	folder.Schemes = []NamingScheme{TOSECScheme{}}
+	files, err = folder.GetFiles()
+	if err != nil {
+		t.Fatalf("GetFiles() failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Scheme != "tosec" {
+		t.Errorf("Expected only the TOSEC file, got %+v", files)
+	}
+}
@@ -0,0 +1,262 @@
+package tosec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/climbus/retro-romkit/internal/tree"
+	"github.com/climbus/retro-romkit/pkg/archive"
+	"github.com/climbus/retro-romkit/pkg/torrentzip"
+)
+
+// PackSource is a file, or a member of an archive, stored in a packed
+// archive.
+type PackSource struct {
+	Path   string // Path of the file or archive in the folder
+	Member string // Path of the member inside the archive, empty for plain files
+	Name   string // Path inside the packed archive
+}
+
+// PackAction describes writing one TorrentZip archive.
+type PackAction struct {
+	Target  string // Path of the archive to write
+	Sources []PackSource
+}
+
+// PackMode selects which files PlanPack stores together in one archive.
+type PackMode string
+
+const (
+	// PackFiles packs every plain file into its own archive and repacks
+	// every archive with all of its members.
+	PackFiles PackMode = "files"
+	// PackGames packs all media of a game, e.g. "Disk 1 of 2" and
+	// "Disk 2 of 2", into one archive named after the game.
+	PackGames PackMode = "games"
+	// PackSplit packs every file, including every member of an archive,
+	// into its own archive, undoing PackGames.
+	PackSplit PackMode = "split"
+)
+
+// PackOptions configures PlanPack.
+type PackOptions struct {
+	Output string   // Directory to write the archives to
+	Mode   PackMode // Grouping of files into archives, PackFiles when empty
+}
+
+// PlanPack proposes packing the files in the folder into TorrentZip
+// archives in opts.Output, keeping their path relative to the folder.
+// Files are grouped into archives as selected by opts.Mode; archives are
+// named after their file, game or source archive, without extension.
+// With PackGames, files whose names cannot be parsed are returned as parse
+// errors and packed on their own. Files whose target already exists, or
+// which have the same name as another file of their archive, are returned
+// as parse errors.
+func (tosecFolder *Folder) PlanPack(opts PackOptions) ([]PackAction, []ParseError, error) {
+	var planned []*PackAction
+	byTarget := make(map[string]*PackAction)
+	var skipped []ParseError
+
+	entries, errCh := tosecFolder.walkFiles()
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		sources, err := packSources(entry.Path)
+		if err != nil {
+			skipped = append(skipped, ParseError{FileName: entry.Name, Error: err})
+			continue
+		}
+		for _, source := range sources {
+			name, err := tosecFolder.packName(entry.Path, source, opts.Mode)
+			if err != nil {
+				skipped = append(skipped, ParseError{FileName: source.Name, Error: err})
+			}
+			target := filepath.Join(opts.Output, entry.Folder, name+".zip")
+			action, ok := byTarget[target]
+			if !ok && fileExists(target) {
+				skipped = append(skipped, ParseError{FileName: source.Name, Error: fmt.Errorf("%w: %s", ErrTargetExists, target)})
+				continue
+			}
+			if !ok {
+				action = &PackAction{Target: target}
+				byTarget[target] = action
+				planned = append(planned, action)
+			}
+			if slices.ContainsFunc(action.Sources, func(other PackSource) bool { return other.Name == source.Name }) {
+				skipped = append(skipped, ParseError{FileName: source.Name, Error: fmt.Errorf("%w: %s in %s", ErrTargetExists, source.Name, target)})
+				continue
+			}
+			action.Sources = append(action.Sources, source)
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+
+	actions := make([]PackAction, len(planned))
+	for i, action := range planned {
+		actions[i] = *action
+	}
+	return actions, skipped, nil
+}
+
+// ApplyPack writes the planned archives. Existing files are never
+// overwritten.
+func ApplyPack(actions []PackAction) error {
+	for _, action := range actions {
+		if err := os.MkdirAll(filepath.Dir(action.Target), 0755); err != nil {
+			return err
+		}
+		if err := applyPackAction(action); err != nil {
+			return fmt.Errorf("failed to pack '%s': %w", action.Target, err)
+		}
+	}
+	return nil
+}
+
+// applyPackAction writes a single archive. Archive members are extracted to
+// a temporary directory first, reading every source archive once, as
+// TorrentZip stores files in an order of its own.
+func applyPackAction(action PackAction) error {
+	tmpDir, err := os.MkdirTemp("", "romkit-pack-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	extracted, err := extractPackSources(action.Sources, tmpDir)
+	if err != nil {
+		return err
+	}
+	sources := make([]torrentzip.Source, len(action.Sources))
+	for i, source := range action.Sources {
+		file := source.Path
+		if source.Member != "" {
+			file = extracted[source]
+		}
+		sources[i] = torrentzip.Source{Name: source.Name, Open: func() (io.ReadCloser, error) { return os.Open(file) }}
+	}
+	return torrentzip.Create(action.Target, sources)
+}
+
+// extractPackSources extracts the archive members among the sources to
+// files in dir and returns their paths.
+func extractPackSources(sources []PackSource, dir string) (map[PackSource]string, error) {
+	byArchive := make(map[string][]PackSource)
+	for _, source := range sources {
+		if source.Member != "" {
+			byArchive[source.Path] = append(byArchive[source.Path], source)
+		}
+	}
+
+	extracted := make(map[PackSource]string)
+	for archivePath, members := range byArchive {
+		err := archive.Walk(archivePath, func(f archive.File, r io.Reader) error {
+			i := slices.IndexFunc(members, func(source PackSource) bool { return source.Member == f.Name })
+			if i < 0 {
+				return nil
+			}
+			target := filepath.Join(dir, fmt.Sprint(len(extracted)))
+			if err := writeNewFile(target, r); err != nil {
+				return err
+			}
+			extracted[members[i]] = target
+			members = slices.Delete(members, i, i+1)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(members) > 0 {
+			return nil, fmt.Errorf("'%s' not found in '%s'", members[0].Member, archivePath)
+		}
+	}
+	return extracted, nil
+}
+
+// VerifyTorrentZip checks every zip archive in the folder and returns the
+// number of archives checked. Archives which are not TorrentZipped are
+// returned as parse errors with the reason.
+func (tosecFolder *Folder) VerifyTorrentZip() (int, []ParseError, error) {
+	checked := 0
+	var failed []ParseError
+
+	entries, errCh := tosecFolder.walkFiles()
+	for entry := range entries {
+		if entry.IsDir || !strings.EqualFold(filepath.Ext(entry.Path), ".zip") {
+			continue
+		}
+		checked++
+		if err := torrentzip.Verify(entry.Path); err != nil {
+			failed = append(failed, ParseError{FileName: filepath.Join(entry.Folder, entry.Name), Error: err})
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return 0, nil, err
+	}
+
+	return checked, failed, nil
+}
+
+// packSources returns the files to pack from the file at path: the file
+// itself, or every member of an archive.
+func packSources(file string) ([]PackSource, error) {
+	if !archive.IsArchive(file) {
+		return []PackSource{{Path: file, Name: filepath.Base(file)}}, nil
+	}
+
+	var sources []PackSource
+	err := archive.Walk(file, func(f archive.File, _ io.Reader) error {
+		sources = append(sources, PackSource{Path: file, Member: f.Name, Name: f.Name})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("empty archive")
+	}
+	return sources, nil
+}
+
+// packName returns the name, without extension, of the archive the source
+// read from file is packed into. The file name is returned together with
+// the parse error when a game name is needed but cannot be parsed.
+func (tosecFolder *Folder) packName(file string, source PackSource, mode PackMode) (string, error) {
+	base := path.Base(source.Name)
+	name := strings.TrimSuffix(base, path.Ext(base))
+
+	switch mode {
+	case PackGames:
+		tf, err := ParseWithSchemes(base, tosecFolder.namingSchemes())
+		if err != nil {
+			return name, err
+		}
+		return tf.GameName(), nil
+	case PackSplit:
+		return name, nil
+	}
+
+	// A gzip file holds a single file, which names the archive
+	if format, _ := archive.DetectFormat(file); source.Member == "" || format == archive.Gzip {
+		return name, nil
+	}
+	return archive.TrimExt(filepath.Base(file)), nil
+}
+
+// walkFiles returns the tree entries of the folder with archives sent as
+// plain files, whatever ReadArchives is set to.
+func (tosecFolder *Folder) walkFiles() (<-chan tree.Entry, <-chan error) {
+	walked := *tosecFolder
+	walked.ReadArchives = false
+	return walked.GetFileTree()
+}
@@ -0,0 +1,143 @@
+package tosec
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/torrentzip"
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestPlanPack(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	output := filepath.Join(tmpDir, "output")
+
+	writeTestFile(t, filepath.Join(source, "Exolon (1987)(Hewson).d64"), "exolon")
+	testutils.CreateTestZip(t, filepath.Join(source, "Zynaps.zip"), map[string]string{
+		"Zynaps (1987)(Hewson)(Disk 2 of 2).d64": "disk2",
+		"Zynaps (1987)(Hewson)(Disk 1 of 2).d64": "disk1",
+	})
+	testutils.CreateTestGzip(t, filepath.Join(source, "Uridium (1986)(Hewson).d64.gz"), "", "uridium")
+
+	folder := Create(source, "c64")
+	actions, skipped, err := folder.PlanPack(PackOptions{Output: output})
+	if err != nil {
+		t.Fatalf("PlanPack() error = %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("PlanPack() skipped = %+v", skipped)
+	}
+
+	want := map[string]int{
+		"Exolon (1987)(Hewson).zip":  1,
+		"Zynaps.zip":                 2,
+		"Uridium (1986)(Hewson).zip": 1,
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("PlanPack() = %+v, want %d actions", actions, len(want))
+	}
+	for _, action := range actions {
+		if n, ok := want[filepath.Base(action.Target)]; !ok || n != len(action.Sources) {
+			t.Errorf("PlanPack() %s with %d files, want %d", action.Target, len(action.Sources), n)
+		}
+	}
+
+	if err := ApplyPack(actions); err != nil {
+		t.Fatalf("ApplyPack() error = %v", err)
+	}
+	for name := range want {
+		if err := torrentzip.Verify(filepath.Join(output, name)); err != nil {
+			t.Errorf("Verify(%s) error = %v", name, err)
+		}
+	}
+
+	checked, failed, err := folder.VerifyTorrentZip()
+	if err != nil {
+		t.Fatalf("VerifyTorrentZip() error = %v", err)
+	}
+	if checked != 1 || len(failed) != 1 || !errors.Is(failed[0].Error, torrentzip.ErrNotTorrentZip) {
+		t.Errorf("VerifyTorrentZip() = %d, %+v, want Zynaps.zip to fail", checked, failed)
+	}
+	checked, failed, err = Create(output, "c64").VerifyTorrentZip()
+	if err != nil || checked != 3 || len(failed) != 0 {
+		t.Errorf("VerifyTorrentZip() of packed archives = %d, %+v, %v, want 3 passing", checked, failed, err)
+	}
+
+	r, err := zip.OpenReader(filepath.Join(output, "Zynaps.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.File[0].Name != "Zynaps (1987)(Hewson)(Disk 1 of 2).d64" {
+		t.Errorf("first entry = %q, want disk 1", r.File[0].Name)
+	}
+}
+
+func TestPlanPackGames(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	games := filepath.Join(tmpDir, "games")
+	split := filepath.Join(tmpDir, "split")
+
+	writeTestFile(t, filepath.Join(source, "Zynaps (1987)(Hewson)(Disk 1 of 2).d64"), "disk1")
+	writeTestFile(t, filepath.Join(source, "Zynaps (1987)(Hewson)(Disk 2 of 2).d64"), "disk2")
+	writeTestFile(t, filepath.Join(source, "garbage.d64"), "garbage")
+	writeTestFile(t, filepath.Join(source, "Maniac Mansion (Europe) (Disk 1).d64"), "maniac1")
+	writeTestFile(t, filepath.Join(source, "Maniac Mansion (Europe) (Disk 2).d64"), "maniac2")
+	testutils.CreateTestZip(t, filepath.Join(source, "Exolon.zip"), map[string]string{
+		"Exolon (1987)(Hewson)(Side A).d64": "side a",
+		"Exolon (1987)(Hewson)(Side B).d64": "side b",
+	})
+
+	actions, skipped, err := Create(source, "c64").PlanPack(PackOptions{Output: games, Mode: PackGames})
+	if err != nil {
+		t.Fatalf("PlanPack() error = %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].FileName != "garbage.d64" {
+		t.Errorf("PlanPack() skipped = %+v, want the unparseable garbage.d64", skipped)
+	}
+	want := map[string]int{
+		"Zynaps (1987)(Hewson).zip":   2,
+		"Exolon (1987)(Hewson).zip":   2,
+		"Maniac Mansion (Europe).zip": 2,
+		"garbage.zip":                 1,
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("PlanPack() = %+v, want %d archives", actions, len(want))
+	}
+	for _, action := range actions {
+		if n := want[filepath.Base(action.Target)]; n != len(action.Sources) {
+			t.Errorf("PlanPack() %s with %d files, want %d", action.Target, len(action.Sources), n)
+		}
+	}
+	if err := ApplyPack(actions); err != nil {
+		t.Fatalf("ApplyPack() error = %v", err)
+	}
+
+	actions, skipped, err = Create(games, "c64").PlanPack(PackOptions{Output: split, Mode: PackSplit})
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("PlanPack() error = %v, skipped = %+v", err, skipped)
+	}
+	if err := ApplyPack(actions); err != nil {
+		t.Fatalf("ApplyPack() error = %v", err)
+	}
+	for _, name := range []string{
+		"Zynaps (1987)(Hewson)(Disk 1 of 2).zip",
+		"Zynaps (1987)(Hewson)(Disk 2 of 2).zip",
+		"Exolon (1987)(Hewson)(Side A).zip",
+		"Exolon (1987)(Hewson)(Side B).zip",
+		"Maniac Mansion (Europe) (Disk 1).zip",
+		"Maniac Mansion (Europe) (Disk 2).zip",
+		"garbage.zip",
+	} {
+		if err := torrentzip.Verify(filepath.Join(split, name)); err != nil {
+			t.Errorf("Verify(%s) error = %v", name, err)
+		}
+	}
+}
@@ -3,12 +3,15 @@ package tosec
 import (
 	"maps"
 	"slices"
+
+	"github.com/climbus/retro-romkit/pkg/checksum"
 )
 
 type Platform struct {
 	Name        string
 	Description string
 	FileTypes   []string
+	Detector    *checksum.Detector // Copier header detector, nil when dumps are headerless
 }
 
 var Platforms = map[string]Platform{
@@ -16,6 +19,7 @@ var Platforms = map[string]Platform{
 		Name:        "nes",
 		Description: "Nintendo Entertainment System",
 		FileTypes:   []string{".nes", ".fds"},
+		Detector:    checksum.NESDetector,
 	},
 	"snes": {
 		Name:        "snes",
@@ -37,6 +41,18 @@ var Platforms = map[string]Platform{
 		Description: "Atari 2600",
 		FileTypes:   []string{".a26", ".bin"},
 	},
+	"atari7800": {
+		Name:        "atari7800",
+		Description: "Atari 7800",
+		FileTypes:   []string{".a78", ".bin"},
+		Detector:    checksum.Atari7800Detector,
+	},
+	"lynx": {
+		Name:        "lynx",
+		Description: "Atari Lynx",
+		FileTypes:   []string{".lnx", ".lyx"},
+		Detector:    checksum.LynxDetector,
+	},
 	"c64": {
 		Name:        "c64",
 		Description: "Commodore 64",
@@ -0,0 +1,231 @@
+package tosec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/climbus/retro-romkit/internal/tree"
+	"github.com/climbus/retro-romkit/pkg/archive"
+	"github.com/climbus/retro-romkit/pkg/checksum"
+	"github.com/climbus/retro-romkit/pkg/dat"
+)
+
+// RebuildAction describes placing one scanned file, or one member of a
+// scanned archive, at its target path.
+type RebuildAction struct {
+	Source  string // Path of the scanned file
+	Member  string // Name of the archive member, empty for plain files
+	Target  string // Destination path
+	Rom     string // Matched DAT rom, empty for unknown files
+	Unknown bool   // Set when the file does not match the DAT
+}
+
+// PlanRebuild matches every file in the folder by checksum against the DAT.
+// Matched files are placed at output/<DAT name>/<rom name>; files which do
+// not match are placed in unknownDir, keeping their path relative to the
+// folder. A file matching several roms is placed at every one of them.
+// Members of archives are matched one by one; an archive goes to
+// unknownDir only if none of its members match, otherwise its unmatched
+// members are extracted there, below the archive name. Files whose target
+// already exists, whose rom was matched before, or which cannot be hashed
+// are returned as parse errors. A DAT whose name is empty or not a plain
+// directory name is rejected.
+func (tosecFolder *Folder) PlanRebuild(datafile *dat.Datafile, output, unknownDir string) ([]RebuildAction, []ParseError, error) {
+	if datafile.Header.Name == "" || strings.ContainsAny(datafile.Header.Name, `/\`) {
+		return nil, nil, fmt.Errorf("invalid DAT name '%s'", datafile.Header.Name)
+	}
+	datDir, err := safeJoin(output, datafile.Header.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx := dat.NewIndex(datafile)
+	actions := make([]RebuildAction, 0)
+	var skipped []ParseError
+	targets := make(map[string]bool)
+
+	plan := func(entry tree.Entry, action RebuildAction) {
+		if targets[action.Target] || fileExists(action.Target) {
+			skipped = append(skipped, ParseError{FileName: entry.Name, Error: fmt.Errorf("%w: %s", ErrTargetExists, action.Target)})
+			return
+		}
+		targets[action.Target] = true
+		actions = append(actions, action)
+	}
+
+	entries, errCh := tosecFolder.GetHashedFileTree()
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if entry.Err != nil {
+			skipped = append(skipped, ParseError{FileName: entry.Name, Error: entry.Err})
+			continue
+		}
+		entryActions, errs := rebuildEntry(entry, idx, datDir, unknownDir)
+		skipped = append(skipped, errs...)
+		for _, action := range entryActions {
+			plan(entry, action)
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+
+	return actions, skipped, nil
+}
+
+// rebuildEntry returns the actions placing the file, or the members of the
+// archive, of a hashed entry, together with the errors of members whose
+// names are unsafe.
+func rebuildEntry(entry tree.Entry, idx *dat.Index, datDir, unknownDir string) ([]RebuildAction, []ParseError) {
+	var actions []RebuildAction
+	var errs []ParseError
+	var unmatched []checksum.Sums
+	for _, sums := range entry.Sums {
+		matches := idx.Lookup(sums)
+		if len(matches) == 0 {
+			unmatched = append(unmatched, sums)
+			continue
+		}
+		for _, match := range matches {
+			target, err := safeJoin(datDir, match.Rom.Name)
+			if err != nil {
+				errs = append(errs, ParseError{FileName: entry.Name, Error: err})
+				continue
+			}
+			actions = append(actions, RebuildAction{
+				Source: entrySource(entry),
+				Member: archiveMember(entry, sums),
+				Target: target,
+				Rom:    match.Rom.Name,
+			})
+		}
+	}
+
+	unknownPath := filepath.Join(unknownDir, entry.Folder, entry.Name)
+	if len(unmatched) == len(entry.Sums) {
+		action := RebuildAction{Source: entrySource(entry), Member: archiveMember(entry, checksum.Sums{}), Target: unknownPath, Unknown: true}
+		return append(actions, action), errs
+	}
+	for _, sums := range unmatched {
+		target, err := safeJoin(unknownPath, sums.Name)
+		if err != nil {
+			errs = append(errs, ParseError{FileName: entry.Name, Error: err})
+			continue
+		}
+		actions = append(actions, RebuildAction{Source: entrySource(entry), Member: sums.Name, Target: target, Unknown: true})
+	}
+	return actions, errs
+}
+
+// ApplyRebuild carries out the planned actions. Plain files are moved when
+// move is set and copied otherwise; archive members are always
+// extracted, leaving the archive in place. A file placed at several targets
+// is moved to the first and copied from there to the others. Existing files
+// are never overwritten.
+func ApplyRebuild(actions []RebuildAction, move bool) error {
+	moved := make(map[string]string)
+	for _, action := range actions {
+		if fileExists(action.Target) {
+			return fmt.Errorf("%w: %s", ErrTargetExists, action.Target)
+		}
+		if err := os.MkdirAll(filepath.Dir(action.Target), 0755); err != nil {
+			return err
+		}
+
+		var err error
+		switch {
+		case action.Member != "":
+			err = extractMember(action.Source, action.Member, action.Target)
+		case moved[action.Source] != "":
+			err = copyFile(moved[action.Source], action.Target)
+		case move:
+			err = moveFile(action.Source, action.Target)
+			moved[action.Source] = action.Target
+		default:
+			err = copyFile(action.Source, action.Target)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to rebuild '%s': %w", action.Source, err)
+		}
+	}
+	return nil
+}
+
+// safeJoin joins dir and a name taken from a DAT or an archive, rejecting
+// names which would escape dir.
+func safeJoin(dir, name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("unsafe file name '%s'", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), nil
+}
+
+// entrySource returns the path of the file holding the entry on disk.
+func entrySource(entry tree.Entry) string {
+	if entry.Archive != "" {
+		return entry.Archive
+	}
+	return entry.Path
+}
+
+func archiveMember(entry tree.Entry, sums checksum.Sums) string {
+	if entry.Archive != "" {
+		return entry.Path
+	}
+	if !archive.IsArchive(entry.Path) {
+		return ""
+	}
+	return sums.Name
+}
+
+func extractMember(archivePath, member, target string) error {
+	w, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err := archive.Extract(archivePath, member, w); err != nil {
+		w.Close()
+		os.Remove(target)
+		return err
+	}
+	return w.Close()
+}
+
+func moveFile(source, target string) error {
+	if err := os.Rename(source, target); err == nil {
+		return nil
+	}
+	// Renaming fails across file systems, fall back to copy and remove
+	if err := copyFile(source, target); err != nil {
+		return err
+	}
+	return os.Remove(source)
+}
+
+func copyFile(source, target string) error {
+	r, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return writeNewFile(target, r)
+}
+
+func writeNewFile(target string, r io.Reader) error {
+	w, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		os.Remove(target)
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,236 @@
+package tosec
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestRebuild(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	output := filepath.Join(tmpDir, "output")
+	unknown := filepath.Join(tmpDir, "unknown")
+
+	writeTestFile(t, filepath.Join(source, "zynaps.d64"), "hello")
+	writeTestFile(t, filepath.Join(source, "sub", "random.d64"), "random")
+	writeTestFile(t, filepath.Join(source, "copy of zynaps.d64"), "hello")
+	archive := filepath.Join(source, "ninja.zip")
+	testutils.CreateTestZip(t, archive, map[string]string{"disk1.d64": "disk one", "disk2.d64": "disk two"})
+
+	datafile := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Games"},
+		Games: []dat.Game{
+			{Name: "Zynaps (1987)(Hewson)", Roms: []dat.Rom{{Name: "Zynaps (1987)(Hewson).d64", Size: 5, CRC: "3610a686"}}},
+			{Name: "Last Ninja, The (1987)(System 3)", Roms: []dat.Rom{
+				{Name: "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64", Size: 8, CRC: crc("disk one")},
+				{Name: "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64", Size: 8, CRC: crc("disk two")},
+			}},
+			{Name: "Evil", Roms: []dat.Rom{{Name: "../evil.d64", Size: 6, CRC: crc("random")}}},
+		},
+	}
+
+	tosecFolder := Create(source, "c64")
+	actions, skipped, err := tosecFolder.PlanRebuild(datafile, output, unknown)
+	if err != nil {
+		t.Fatalf("PlanRebuild() error = %v", err)
+	}
+	if len(actions) != 3 {
+		t.Fatalf("PlanRebuild() planned %d actions, want 3: %+v", len(actions), actions)
+	}
+	if len(skipped) != 2 {
+		t.Fatalf("PlanRebuild() skipped %d files, want 2: %+v", len(skipped), skipped)
+	}
+	for _, s := range skipped {
+		if s.FileName == "random.d64" {
+			continue
+		}
+		if !errors.Is(s.Error, ErrTargetExists) {
+			t.Errorf("skipped %q error = %v, want ErrTargetExists", s.FileName, s.Error)
+		}
+	}
+
+	if err := ApplyRebuild(actions, true); err != nil {
+		t.Fatalf("ApplyRebuild() error = %v", err)
+	}
+
+	datDir := filepath.Join(output, "Commodore C64 - Games")
+	wantFiles := map[string]string{
+		filepath.Join(datDir, "Zynaps (1987)(Hewson).d64"):                         "hello",
+		filepath.Join(datDir, "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64"): "disk one",
+		filepath.Join(datDir, "Last Ninja, The (1987)(System 3)(Disk 2 of 2).d64"): "disk two",
+	}
+	for path, content := range wantFiles {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("expected rebuilt file %s: %v", path, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s content = %q, want %q", path, got, content)
+		}
+	}
+	if !fileExists(archive) {
+		t.Error("zip archive should be kept after extracting its members")
+	}
+	if fileExists(filepath.Join(tmpDir, "evil.d64")) {
+		t.Error("rom name escaping the output directory was written")
+	}
+}
+
+func TestRebuildUnknown(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	unknown := filepath.Join(tmpDir, "unknown")
+
+	writeTestFile(t, filepath.Join(source, "sub", "random.d64"), "random")
+
+	tosecFolder := Create(source, "c64")
+	actions, _, err := tosecFolder.PlanRebuild(&dat.Datafile{Header: dat.Header{Name: "Empty"}}, filepath.Join(tmpDir, "output"), unknown)
+	if err != nil {
+		t.Fatalf("PlanRebuild() error = %v", err)
+	}
+	if err := ApplyRebuild(actions, false); err != nil {
+		t.Fatalf("ApplyRebuild() error = %v", err)
+	}
+
+	if !fileExists(filepath.Join(unknown, "sub", "random.d64")) {
+		t.Error("unmatched file should be copied to the unknown directory")
+	}
+	if !fileExists(filepath.Join(source, "sub", "random.d64")) {
+		t.Error("source file should be kept when copying")
+	}
+}
+
+func TestRebuildPartlyMatchedArchive(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	output := filepath.Join(tmpDir, "output")
+	unknown := filepath.Join(tmpDir, "unknown")
+
+	archive := filepath.Join(source, "sub", "ninja.zip")
+	testutils.CreateTestZip(t, archive, map[string]string{"disk1.d64": "disk one", "notes.d64": "notes"})
+	datafile := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Games"},
+		Games: []dat.Game{{Name: "Last Ninja, The (1987)(System 3)", Roms: []dat.Rom{
+			{Name: "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64", Size: 8, CRC: crc("disk one")},
+		}}},
+	}
+
+	actions, skipped, err := Create(source, "c64").PlanRebuild(datafile, output, unknown)
+	if err != nil {
+		t.Fatalf("PlanRebuild() error = %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("PlanRebuild() skipped = %+v, want none", skipped)
+	}
+	want := []RebuildAction{
+		{Source: archive, Member: "disk1.d64", Target: filepath.Join(output, "Commodore C64 - Games", "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64"), Rom: "Last Ninja, The (1987)(System 3)(Disk 1 of 2).d64"},
+		{Source: archive, Member: "notes.d64", Target: filepath.Join(unknown, "sub", "ninja.zip", "notes.d64"), Unknown: true},
+	}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("PlanRebuild() actions = %+v, want %+v", actions, want)
+	}
+
+	if err := ApplyRebuild(actions, true); err != nil {
+		t.Fatalf("ApplyRebuild() error = %v", err)
+	}
+	if got, err := os.ReadFile(want[1].Target); err != nil || string(got) != "notes" {
+		t.Errorf("unmatched member = %q, %v, want it extracted to the unknown directory", got, err)
+	}
+}
+
+func TestRebuildSharedContents(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+	output := filepath.Join(tmpDir, "output")
+
+	writeTestFile(t, filepath.Join(source, "uridium.d64"), "uridium")
+	datafile := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Games"},
+		Games: []dat.Game{
+			{Name: "Hewson Hits (1987)(Hewson)", Roms: []dat.Rom{{Name: "Hewson Hits (1987)(Hewson).d64", Size: 7, CRC: crc("uridium")}}},
+			{Name: "Uridium (1986)(Hewson)", Roms: []dat.Rom{{Name: "Uridium (1986)(Hewson).d64", Size: 7, CRC: crc("uridium")}}},
+		},
+	}
+
+	actions, _, err := Create(source, "c64").PlanRebuild(datafile, output, filepath.Join(tmpDir, "unknown"))
+	if err != nil {
+		t.Fatalf("PlanRebuild() error = %v", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("PlanRebuild() planned %d actions, want one per rom: %+v", len(actions), actions)
+	}
+	if err := ApplyRebuild(actions, true); err != nil {
+		t.Fatalf("ApplyRebuild() error = %v", err)
+	}
+	for _, game := range datafile.Games {
+		if !fileExists(filepath.Join(output, datafile.Header.Name, game.Roms[0].Name)) {
+			t.Errorf("expected rebuilt file %s", game.Roms[0].Name)
+		}
+	}
+	if fileExists(filepath.Join(source, "uridium.d64")) {
+		t.Error("source file should be moved")
+	}
+}
+
+func TestRebuildSkipsUnreadableFiles(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "source")
+
+	writeTestFile(t, filepath.Join(source, "zynaps.d64"), "hello")
+	writeTestFile(t, filepath.Join(source, "broken.zip"), "not a zip")
+	datafile := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Games"},
+		Games:  []dat.Game{{Name: "Zynaps", Roms: []dat.Rom{{Name: "Zynaps (1987)(Hewson).d64", Size: 5, CRC: "3610a686"}}}},
+	}
+
+	actions, skipped, err := Create(source, "c64").PlanRebuild(datafile, filepath.Join(tmpDir, "output"), filepath.Join(tmpDir, "unknown"))
+	if err != nil {
+		t.Fatalf("PlanRebuild() error = %v", err)
+	}
+	if len(actions) != 1 || actions[0].Rom != "Zynaps (1987)(Hewson).d64" {
+		t.Errorf("PlanRebuild() actions = %+v, want the Zynaps rom", actions)
+	}
+	if len(skipped) != 1 || skipped[0].FileName != "broken.zip" {
+		t.Errorf("PlanRebuild() skipped = %+v, want broken.zip", skipped)
+	}
+}
+
+func TestRebuildRejectsUnsafeDatName(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"", "../escape", "sub/dir", "/abs"} {
+		datafile := &dat.Datafile{Header: dat.Header{Name: name}}
+		if _, _, err := Create(tmpDir, "c64").PlanRebuild(datafile, filepath.Join(tmpDir, "output"), filepath.Join(tmpDir, "unknown")); err == nil {
+			t.Errorf("PlanRebuild() with DAT name %q succeeded, want an error", name)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func crc(content string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(content)))
+}
@@ -0,0 +1,347 @@
+package tosec
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/climbus/retro-romkit/pkg/archive"
+	"github.com/climbus/retro-romkit/pkg/checksum"
+	"github.com/climbus/retro-romkit/pkg/dat"
+	"golang.org/x/text/unicode/norm"
+)
+
+const regexRepairDate = `^(.*?)\s*\(([0-9x]{4}(?:-[0-9x]{2}){0,2})\)\s*(.*)$`
+const regexRepairWildcards = `\(\d[0-9xX?]{3}(?:-[0-9xX?]{2}){0,2}\)`
+const regexRepairToken = `^\s*(\([^)]*\)|\[[^\]]*\])`
+
+var (
+	reRepairDate      = regexp.MustCompile(regexRepairDate)
+	reRepairWildcards = regexp.MustCompile(regexRepairWildcards)
+	reRepairToken     = regexp.MustCompile(regexRepairToken)
+)
+
+// ErrTargetExists is returned when a rename would overwrite an existing file.
+var ErrTargetExists = errors.New("target file already exists")
+
+// ErrContentMismatch is returned when a file does not hold the rom it is
+// named after.
+var ErrContentMismatch = errors.New("file contents do not match the DAT")
+
+// Rename describes a single file rename within a directory.
+type Rename struct {
+	Dir  string `json:"dir"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RepairFileName applies heuristic fixes to a near-compliant file name and
+// returns its canonical TOSEC form. Repairs include normalizing spaces,
+// removing illegal characters, adding the missing publisher placeholder,
+// moving parenthesized fields before flags and sorting fields and flags.
+func RepairFileName(fileName string) (string, error) {
+	name, ext := splitExtension(fileName)
+	name = reRepairWildcards.ReplaceAllStringFunc(name, normalizeDateWildcards)
+	name, err := removeIllegalCharacters(name)
+	if err != nil {
+		return "", fmt.Errorf("cannot repair '%s': %w", fileName, err)
+	}
+	name = normalizeSpaces(name)
+
+	matches := reRepairDate.FindStringSubmatch(name)
+	if matches == nil {
+		return "", fmt.Errorf("cannot repair '%s': no date field found", fileName)
+	}
+	title, date, rest := matches[1], matches[2], matches[3]
+
+	options, flags, err := splitRepairTokens(rest)
+	if err != nil {
+		return "", fmt.Errorf("cannot repair '%s': %w", fileName, err)
+	}
+	if len(options) == 0 || isOptionalField(options[0]) {
+		options = append([]string{"(" + noPublisher + ")"}, options...)
+	}
+
+	repaired := title + " (" + date + ")" + strings.Join(options, "") + strings.Join(flags, "") + ext
+	tf, err := ParseFileName(repaired)
+	if err != nil {
+		return "", fmt.Errorf("cannot repair '%s': %w", fileName, err)
+	}
+	return FormatFileName(tf), nil
+}
+
+// PlanRenames proposes a repaired name for every file in the folder whose
+// name is not canonical. Files which cannot be repaired are returned as
+// parse errors; renames which would collide with other files are skipped.
+// Directories of the renames are absolute, so that an undo log can be
+// replayed from any working directory.
+func (tosecFolder *Folder) PlanRenames() ([]Rename, []ParseError, error) {
+	root, err := filepath.Abs(tosecFolder.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, errCh := tosecFolder.GetFileTree()
+	renames := make([]Rename, 0)
+	var parseErrors []ParseError
+	targets := make(map[string]bool)
+
+	for entry := range entries {
+		if entry.IsDir || entry.Archive != "" {
+			continue // Archive members cannot be renamed in place
+		}
+		repaired, err := RepairFileName(entry.Name)
+		if err != nil {
+			parseErrors = append(parseErrors, ParseError{FileName: entry.Name, Error: err})
+			continue
+		}
+		if repaired == entry.Name {
+			continue
+		}
+
+		dir := filepath.Join(root, entry.Folder)
+		target := filepath.Join(dir, repaired)
+		if targets[target] || fileExists(target) {
+			parseErrors = append(parseErrors, ParseError{FileName: entry.Name, Error: fmt.Errorf("%w: %s", ErrTargetExists, repaired)})
+			continue
+		}
+		targets[target] = true
+		renames = append(renames, Rename{Dir: dir, From: entry.Name, To: repaired})
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+
+	return renames, parseErrors, nil
+}
+
+// PlanDatRenames proposes renames for the files in the folder which carry
+// the old name of a rom renamed between two DAT releases, and for the
+// archives named after a renamed game, so that the collection follows the
+// new release without rescanning it. Files are only renamed when their
+// size and checksums match the old rom, and archives when every contained
+// file matches a rom of the old game; others are skipped. Archive members
+// keep their names. Directories of the renames are absolute, like those
+// planned by PlanRenames.
+func (tosecFolder *Folder) PlanDatRenames(diff dat.Diff) ([]Rename, []ParseError, error) {
+	roms := make(map[string]dat.RomChange)
+	for _, change := range diff.Renamed {
+		roms[path.Base(change.Old.Name)] = change
+	}
+	games := make(map[string]dat.GameChange)
+	for _, change := range diff.RenamedGames {
+		games[change.Old.Name] = change
+	}
+
+	entries, errCh := tosecFolder.GetFileTree()
+	hasher := checksum.Hasher{Detector: tosecFolder.Detector}
+	renames := make([]Rename, 0)
+	var skipped []ParseError
+	targets := make(map[string]bool)
+
+	for entry := range entries {
+		if entry.Archive != "" || (entry.IsDir && !archive.IsArchive(entry.Path)) {
+			continue // Archive members cannot be renamed in place
+		}
+		from := filepath.Base(entry.Path)
+		newName, game, ok := datRenameTarget(from, roms, games)
+		if !ok {
+			continue
+		}
+		if err := checkDatRename(hasher, entry.Path, game); err != nil {
+			skipped = append(skipped, ParseError{FileName: from, Error: err})
+			continue
+		}
+
+		dir, err := filepath.Abs(filepath.Dir(entry.Path))
+		if err != nil {
+			skipped = append(skipped, ParseError{FileName: from, Error: err})
+			continue
+		}
+		target := filepath.Join(dir, newName)
+		if targets[target] || fileExists(target) {
+			skipped = append(skipped, ParseError{FileName: from, Error: fmt.Errorf("%w: %s", ErrTargetExists, newName)})
+			continue
+		}
+		targets[target] = true
+		renames = append(renames, Rename{Dir: dir, From: from, To: newName})
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+
+	return renames, skipped, nil
+}
+
+// datRenameTarget returns the new name of a file renamed between two DAT
+// releases, together with the old game holding the roms it must contain.
+func datRenameTarget(fileName string, roms map[string]dat.RomChange, games map[string]dat.GameChange) (string, dat.Game, bool) {
+	if archive.IsArchive(fileName) {
+		ext := filepath.Ext(fileName)
+		change, ok := games[strings.TrimSuffix(fileName, ext)]
+		return change.New.Name + ext, change.Old, ok
+	}
+	change, ok := roms[fileName]
+	return path.Base(change.New.Name), dat.Game{Roms: []dat.Rom{change.Old}}, ok
+}
+
+// checkDatRename checks that every file at path, or contained in it for
+// archives, matches a rom of the game.
+func checkDatRename(hasher checksum.Hasher, path string, game dat.Game) error {
+	sums, err := hasher.File(path)
+	if err != nil {
+		return err
+	}
+	if len(sums) == 0 {
+		return fmt.Errorf("%w: empty archive", ErrContentMismatch)
+	}
+	idx := dat.NewIndex(&dat.Datafile{Games: []dat.Game{game}})
+	for _, s := range sums {
+		if idx.Lookup(s) == nil {
+			return fmt.Errorf("%w: %s", ErrContentMismatch, s.Name)
+		}
+	}
+	return nil
+}
+
+// ApplyRenames renames the files and writes one JSON line per applied rename
+// to undoLog, so that the operation can be reverted with UndoRenames.
+func ApplyRenames(renames []Rename, undoLog io.Writer) error {
+	encoder := json.NewEncoder(undoLog)
+	for _, r := range renames {
+		if err := renameFile(r.Dir, r.From, r.To); err != nil {
+			return err
+		}
+		if err := encoder.Encode(r); err != nil {
+			return fmt.Errorf("failed to write undo log: %w", err)
+		}
+	}
+	return nil
+}
+
+// UndoRenames reverts the renames recorded by ApplyRenames, newest first.
+func UndoRenames(undoLog io.Reader) error {
+	var renames []Rename
+	scanner := bufio.NewScanner(undoLog)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var r Rename
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("invalid undo log entry: %w", err)
+		}
+		renames = append(renames, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range slices.Backward(renames) {
+		if err := renameFile(r.Dir, r.To, r.From); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitRepairTokens splits the part of a name after the date into
+// parenthesized options and bracketed flags, keeping their relative order.
+func splitRepairTokens(rest string) ([]string, []string, error) {
+	var options, flags []string
+	for strings.TrimSpace(rest) != "" {
+		loc := reRepairToken.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			return nil, nil, fmt.Errorf("unexpected text '%s'", strings.TrimSpace(rest))
+		}
+		token := rest[loc[2]:loc[3]]
+		if strings.HasPrefix(token, "(") {
+			options = append(options, token)
+		} else {
+			flags = append(flags, token)
+		}
+		rest = rest[loc[1]:]
+	}
+	return options, flags, nil
+}
+
+func splitExtension(fileName string) (string, string) {
+	ext := filepath.Ext(fileName)
+	if strings.ContainsAny(ext, " )]") {
+		return fileName, ""
+	}
+	return strings.TrimSuffix(fileName, ext), ext
+}
+
+// isOptionalField reports whether a parenthesized token is one of the
+// optional fields following the publisher, so that it cannot be the
+// publisher itself.
+func isOptionalField(token string) bool {
+	opt := strings.TrimSpace(strings.Trim(token, "()"))
+	return (&File{}).applyOption(opt, fieldNone) != fieldNone
+}
+
+// removeIllegalCharacters drops the characters TOSEC forbids and
+// transliterates accented letters to ASCII. Letters without an ASCII
+// spelling are reported as an error rather than removed.
+func removeIllegalCharacters(value string) (string, error) {
+	value = strings.ReplaceAll(value, ":", " -")
+	value = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(illegalCharacters, r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+	return transliterate(value)
+}
+
+// transliterations spells out letters which do not decompose into an ASCII
+// letter and a combining mark.
+var transliterations = map[rune]string{
+	'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'ß': "ss", 'Ø': "O", 'ø': "o",
+	'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d", 'Þ': "Th", 'þ': "th", 'Ð': "D", 'ð': "d",
+}
+
+func transliterate(value string) (string, error) {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(value) {
+		switch {
+		case r <= '~':
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// Drop the accents of decomposed letters
+		case transliterations[r] != "":
+			b.WriteString(transliterations[r])
+		default:
+			return "", fmt.Errorf("no ASCII spelling for '%c'", r)
+		}
+	}
+	return b.String(), nil
+}
+
+func normalizeDateWildcards(date string) string {
+	return strings.NewReplacer("X", "x", "?", "x").Replace(date)
+}
+
+func renameFile(dir, from, to string) error {
+	target := filepath.Join(dir, to)
+	if fileExists(target) {
+		return fmt.Errorf("%w: %s", ErrTargetExists, target)
+	}
+	return os.Rename(filepath.Join(dir, from), target)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
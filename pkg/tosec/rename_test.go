@@ -0,0 +1,200 @@
+package tosec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestRepairFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+		wantErr  bool
+	}{
+		{"compliant name is kept", "Zynaps (1987)(Hewson).d64", "Zynaps (1987)(Hewson).d64", false},
+		{"missing publisher", "Zynaps (1987).d64", "Zynaps (1987)(-).d64", false},
+		{"missing publisher with flags", "Zynaps (1987)[a].d64", "Zynaps (1987)(-)[a].d64", false},
+		{"flag before media", "Zynaps (1987)(Hewson)[a](Disk 1 of 2).d64", "Zynaps (1987)(Hewson)(Disk 1 of 2)[a].d64", false},
+		{"flags out of order", "Zynaps (1987)(Hewson)[a][cr Fairlight].d64", "Zynaps (1987)(Hewson)[cr Fairlight][a].d64", false},
+		{"fields out of order", "Zynaps (1987)(Hewson)(en)(DE).d64", "Zynaps (1987)(Hewson)(DE)(en).d64", false},
+		{"missing space and extra spaces", "Zynaps  Two(1987) (Hewson) [a].d64", "Zynaps Two (1987)(Hewson)[a].d64", false},
+		{"illegal characters", "Zynaps: Revenge (1987)(Hewson).d64", "Zynaps - Revenge (1987)(Hewson).d64", false},
+		{"accented letters", "Bœuf Élan (1987)(Ubi).d64", "Boeuf Elan (1987)(Ubi).d64", false},
+		{"letters without ASCII spelling", "ゼビウス (1987)(Namco).d64", "", true},
+		{"media without publisher", "Game (1987)[a](Disk 1 of 2).d64", "Game (1987)(-)(Disk 1 of 2)[a].d64", false},
+		{"video without publisher", "Game (1987)(PAL).d64", "Game (1987)(-)(PAL).d64", false},
+		{"language without publisher", "Game (1987)(de).d64", "Game (1987)(-)(de).d64", false},
+		{"country without publisher", "Game (1987)(DE)[a].d64", "Game (1987)(-)(DE)[a].d64", false},
+		{"question mark wildcards", "Zynaps (19??)(Hewson).d64", "Zynaps (19xx)(Hewson).d64", false},
+		{"no date", "Zynaps.d64", "", true},
+		{"garbage after date", "Zynaps (1987) garbage.d64", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepairFileName(tt.fileName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RepairFileName(%q) error = %v, wantErr %v", tt.fileName, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RepairFileName(%q) = %q, want %q", tt.fileName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameAndUndo(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testFiles := []string{
+		"Zynaps (1987)(Hewson).d64",
+		"Uridium (1986).d64",
+		"subdir/Paradroid (1985)(Hewson)[a][cr].d64",
+		"Broken.d64",
+		"Exolon (1987)(-).d64",
+		"Exolon (1987).d64",
+	}
+	testutils.CreateTestFiles(t, testFiles, tmpDir)
+
+	renames, parseErrors, err := Create(tmpDir, "c64").PlanRenames()
+	if err != nil {
+		t.Fatalf("PlanRenames() failed: %v", err)
+	}
+	if len(renames) != 2 {
+		t.Fatalf("Expected 2 renames, got %+v", renames)
+	}
+	if len(parseErrors) != 2 {
+		t.Fatalf("Expected 2 parse errors, got %+v", parseErrors)
+	}
+	if !errors.Is(parseErrors[1].Error, ErrTargetExists) {
+		t.Errorf("Expected collision error, got %v", parseErrors[1].Error)
+	}
+
+	var undoLog bytes.Buffer
+	if err := ApplyRenames(renames, &undoLog); err != nil {
+		t.Fatalf("ApplyRenames() failed: %v", err)
+	}
+	for _, name := range []string{"Uridium (1986)(-).d64", "subdir/Paradroid (1985)(Hewson)[cr][a].d64"} {
+		if !fileExists(filepath.Join(tmpDir, name)) {
+			t.Errorf("Expected %s to exist after rename", name)
+		}
+	}
+
+	if err := UndoRenames(&undoLog); err != nil {
+		t.Fatalf("UndoRenames() failed: %v", err)
+	}
+	for _, name := range testFiles {
+		if !fileExists(filepath.Join(tmpDir, name)) {
+			t.Errorf("Expected %s to exist after undo", name)
+		}
+	}
+}
+
+func TestPlanRenamesAbsoluteDir(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+	testutils.CreateTestFiles(t, []string{"subdir/Uridium (1986).d64"}, tmpDir)
+	t.Chdir(tmpDir)
+
+	renames, _, err := Create(".", "c64").PlanRenames()
+	if err != nil {
+		t.Fatalf("PlanRenames() failed: %v", err)
+	}
+	if len(renames) != 1 || !filepath.IsAbs(renames[0].Dir) {
+		t.Fatalf("PlanRenames() = %+v, want one rename with an absolute directory", renames)
+	}
+	if err := ApplyRenames(renames, io.Discard); err != nil {
+		t.Fatalf("ApplyRenames() failed: %v", err)
+	}
+	if !fileExists(filepath.Join(tmpDir, "subdir", "Uridium (1986)(-).d64")) {
+		t.Error("Expected the file to be renamed")
+	}
+}
+
+func TestPlanDatRenames(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{
+		"subdir/Uridium (1986)(Hewson).d64",
+		"Zynaps (1987)(Hewson).d64",
+		"Zynaps (1987)(Hewson Consultants).d64",
+		"Exolon (1987)(Hewson).d64",
+	}, tmpDir)
+	testutils.CreateTestZip(t, filepath.Join(tmpDir, "Nebulus (1987)(Hewson).zip"), map[string]string{"Nebulus (1987)(Hewson).d64": "nebulus"})
+	testutils.CreateTestZip(t, filepath.Join(tmpDir, "Paradroid (1985)(Hewson).zip"), map[string]string{"Paradroid (1985)(Hewson).d64": "other"})
+
+	empty := dat.Rom{Size: 0, CRC: "00000000"}
+	rom := func(name string, r dat.Rom) dat.Rom {
+		r.Name = name
+		return r
+	}
+	nebulus := dat.Rom{Size: 7, CRC: fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("nebulus")))}
+	diff := dat.Diff{
+		Renamed: []dat.RomChange{
+			{Old: rom("Uridium (1986)(Hewson).d64", empty), New: rom("Uridium (1986)(Hewson Consultants).d64", empty)},
+			{Old: rom("Zynaps (1987)(Hewson).d64", empty), New: rom("Zynaps (1987)(Hewson Consultants).d64", empty)},
+			{Old: rom("Exolon (1987)(Hewson).d64", nebulus), New: rom("Exolon (1987)(Hewson Consultants).d64", nebulus)},
+		},
+		RenamedGames: []dat.GameChange{
+			{
+				Old: dat.Game{Name: "Nebulus (1987)(Hewson)", Roms: []dat.Rom{rom("Nebulus (1987)(Hewson).d64", nebulus)}},
+				New: dat.Game{Name: "Nebulus (1987)(Hewson Consultants)", Roms: []dat.Rom{rom("Nebulus (1987)(Hewson Consultants).d64", nebulus)}},
+			},
+			{
+				Old: dat.Game{Name: "Paradroid (1985)(Hewson)", Roms: []dat.Rom{rom("Paradroid (1985)(Hewson).d64", nebulus)}},
+				New: dat.Game{Name: "Paradroid (1985)(Hewson Consultants)", Roms: []dat.Rom{rom("Paradroid (1985)(Hewson Consultants).d64", nebulus)}},
+			},
+		},
+	}
+
+	renames, skipped, err := Create(tmpDir, "c64").PlanDatRenames(diff)
+	if err != nil {
+		t.Fatalf("PlanDatRenames() failed: %v", err)
+	}
+	want := []Rename{
+		{Dir: tmpDir, From: "Nebulus (1987)(Hewson).zip", To: "Nebulus (1987)(Hewson Consultants).zip"},
+		{Dir: filepath.Join(tmpDir, "subdir"), From: "Uridium (1986)(Hewson).d64", To: "Uridium (1986)(Hewson Consultants).d64"},
+	}
+	if !slices.Equal(renames, want) {
+		t.Errorf("PlanDatRenames() = %+v, want %+v", renames, want)
+	}
+
+	t.Chdir(tmpDir)
+	renames, _, err = Create("subdir", "c64").PlanDatRenames(diff)
+	if err != nil {
+		t.Fatalf("PlanDatRenames() failed: %v", err)
+	}
+	if len(renames) != 1 || renames[0].Dir != filepath.Join(tmpDir, "subdir") {
+		t.Errorf("PlanDatRenames() of a relative path = %+v, want an absolute directory", renames)
+	}
+
+	skippedErrors := make(map[string]error)
+	for _, pe := range skipped {
+		skippedErrors[pe.FileName] = pe.Error
+	}
+	for name, wantErr := range map[string]error{
+		"Zynaps (1987)(Hewson).d64":    ErrTargetExists,
+		"Exolon (1987)(Hewson).d64":    ErrContentMismatch,
+		"Paradroid (1985)(Hewson).zip": ErrContentMismatch,
+	} {
+		if !errors.Is(skippedErrors[name], wantErr) {
+			t.Errorf("Expected %s to be skipped with %v, got %v", name, wantErr, skippedErrors[name])
+		}
+	}
+	if len(skipped) != 3 {
+		t.Errorf("Expected 3 skipped files, got %+v", skipped)
+	}
+}
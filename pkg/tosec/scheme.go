@@ -0,0 +1,81 @@
+package tosec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownScheme is returned when no naming scheme recognizes a file name.
+var ErrUnknownScheme = errors.New("file name does not match any naming scheme")
+
+// NamingScheme parses file names following a particular naming convention
+// into the common File metadata.
+type NamingScheme interface {
+	// Name returns the identifier of the scheme, e.g. "tosec".
+	Name() string
+	// Detect reports whether the file name follows the scheme.
+	Detect(fileName string) bool
+	// Parse parses a file name following the scheme.
+	Parse(fileName string) (*File, error)
+}
+
+// DefaultSchemes lists the naming schemes used when a Folder does not
+// configure its own, in order of detection priority.
+var DefaultSchemes = []NamingScheme{TOSECScheme{}, GoodToolsScheme{}, NoIntroScheme{}}
+
+// TOSECScheme implements the TOSEC naming convention.
+type TOSECScheme struct{}
+
+// Name returns "tosec".
+func (TOSECScheme) Name() string {
+	return "tosec"
+}
+
+// Detect reports whether the file name is a valid TOSEC name.
+func (s TOSECScheme) Detect(fileName string) bool {
+	_, err := ParseFileName(fileName)
+	return err == nil
+}
+
+// Parse parses the file name with ParseFileName.
+func (s TOSECScheme) Parse(fileName string) (*File, error) {
+	tf, err := ParseFileName(fileName)
+	if err != nil {
+		return nil, err
+	}
+	tf.Scheme = s.Name()
+	return tf, nil
+}
+
+// DetectScheme returns the first scheme which recognizes the file name.
+func DetectScheme(fileName string, schemes []NamingScheme) (NamingScheme, bool) {
+	for _, scheme := range schemes {
+		if scheme.Detect(fileName) {
+			return scheme, true
+		}
+	}
+	return nil, false
+}
+
+// ParseWithSchemes detects the naming scheme of the file name and parses it.
+// When no scheme recognizes a name laid out like a TOSEC name, the TOSEC
+// parse error, e.g. ErrInvalidDate, is returned.
+func ParseWithSchemes(fileName string, schemes []NamingScheme) (*File, error) {
+	scheme, ok := DetectScheme(fileName, schemes)
+	if ok {
+		return scheme.Parse(fileName)
+	}
+	for _, scheme := range schemes {
+		if tosec, ok := scheme.(TOSECScheme); ok && reMainData.MatchString(fileName) {
+			return tosec.Parse(fileName)
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrUnknownScheme, fileName)
+}
+
+func (tosecFolder *Folder) namingSchemes() []NamingScheme {
+	if len(tosecFolder.Schemes) == 0 {
+		return DefaultSchemes
+	}
+	return tosecFolder.Schemes
+}
@@ -0,0 +1,149 @@
+package tosec
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const regexVersion = `^(.*?)\s+(v|Rev\s?)(?:(\d+(?:\.\d+)*)([a-z]?)|([A-Z]))$`
+const subtitleSeparator = " - "
+const publisherSeparator = " - "
+const noPublisher = "-"
+
+var reVersion = regexp.MustCompile(regexVersion)
+
+// Articles lists the leading articles TOSEC moves to the end of a title,
+// e.g. "Legend of Zelda, The".
+var Articles = []string{
+	"The", "A", "An",
+	"Der", "Die", "Das", "Ein", "Eine",
+	"Le", "La", "Les", "L'", "Un", "Une",
+	"El", "Los", "Las",
+	"Il", "Lo", "Gli",
+}
+
+// Version represents a version number attached to a title, e.g. "v1.2a",
+// "Rev 1" or "Rev A".
+type Version struct {
+	Prefix  string // "v" or "Rev "
+	Numbers []int
+	Suffix  string
+}
+
+// String returns the version as it appears in the title.
+func (v Version) String() string {
+	numbers := make([]string, len(v.Numbers))
+	for i, n := range v.Numbers {
+		numbers[i] = strconv.Itoa(n)
+	}
+	return v.Prefix + strings.Join(numbers, ".") + v.Suffix
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower, equal to or
+// higher than other. Missing components are treated as zero.
+func (v Version) Compare(other Version) int {
+	for i := range max(len(v.Numbers), len(other.Numbers)) {
+		if c := cmp.Compare(versionPart(v.Numbers, i), versionPart(other.Numbers, i)); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(v.Suffix, other.Suffix)
+}
+
+// Version returns the version suffix of the title, if any.
+func (tf *File) Version() (Version, bool) {
+	_, version, ok := splitVersion(tf.Title)
+	return version, ok
+}
+
+// BaseTitle returns the title without its version suffix.
+func (tf *File) BaseTitle() string {
+	title, _, _ := splitVersion(tf.Title)
+	return title
+}
+
+// DisplayTitle returns the title without version, with a trailing article
+// moved back to the front, e.g. "The Legend of Zelda".
+func (tf *File) DisplayTitle() string {
+	main, subtitle := splitSubtitle(tf.BaseTitle())
+	if article, rest, ok := trailingArticle(main); ok {
+		main = joinArticle(article, rest)
+	}
+	return main + subtitle
+}
+
+// SortTitle returns a case-folded title without version and article,
+// suitable for alphabetical sorting and bucketing.
+func (tf *File) SortTitle() string {
+	main, subtitle := splitSubtitle(tf.BaseTitle())
+	if _, rest, ok := trailingArticle(main); ok {
+		main = rest
+	}
+	return strings.ToLower(main + subtitle)
+}
+
+// Publishers returns the list of publishers. The "-" placeholder used for
+// unknown publishers results in an empty list.
+func (tf *File) Publishers() []string {
+	if tf.Publisher == "" || tf.Publisher == noPublisher {
+		return []string{}
+	}
+	publishers := strings.Split(tf.Publisher, publisherSeparator)
+	for i, publisher := range publishers {
+		publishers[i] = strings.TrimSpace(publisher)
+	}
+	return publishers
+}
+
+func splitVersion(title string) (string, Version, bool) {
+	matches := reVersion.FindStringSubmatch(title)
+	if matches == nil {
+		return title, Version{}, false
+	}
+
+	version := Version{Prefix: matches[2], Suffix: matches[4] + matches[5]}
+	if matches[3] != "" {
+		for _, part := range strings.Split(matches[3], ".") {
+			n, _ := strconv.Atoi(part)
+			version.Numbers = append(version.Numbers, n)
+		}
+	}
+	return matches[1], version, true
+}
+
+func splitSubtitle(title string) (string, string) {
+	idx := strings.Index(title, subtitleSeparator)
+	if idx == -1 {
+		return title, ""
+	}
+	return title[:idx], title[idx:]
+}
+
+func trailingArticle(title string) (string, string, bool) {
+	idx := strings.LastIndex(title, ", ")
+	if idx == -1 {
+		return "", title, false
+	}
+	article := title[idx+2:]
+	if !slices.Contains(Articles, article) {
+		return "", title, false
+	}
+	return article, title[:idx], true
+}
+
+func joinArticle(article, title string) string {
+	if strings.HasSuffix(article, "'") {
+		return article + title
+	}
+	return article + " " + title
+}
+
+func versionPart(numbers []int, i int) int {
+	if i < len(numbers) {
+		return numbers[i]
+	}
+	return 0
+}
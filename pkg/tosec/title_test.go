@@ -0,0 +1,80 @@
+package tosec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTitleAccessors(t *testing.T) {
+	tests := []struct {
+		title       string
+		wantDisplay string
+		wantSort    string
+		wantVersion string
+	}{
+		{"Zynaps", "Zynaps", "zynaps", ""},
+		{"Legend of Zelda, The", "The Legend of Zelda", "legend of zelda", ""},
+		{"Elite v1.2", "Elite", "elite", "v1.2"},
+		{"Bard's Tale, The v2.0a", "The Bard's Tale", "bard's tale", "v2.0a"},
+		{"Addams Family, The - Pugsley's Scavenger Hunt", "The Addams Family - Pugsley's Scavenger Hunt", "addams family - pugsley's scavenger hunt", ""},
+		{"Aventure, L'", "L'Aventure", "aventure", ""},
+		{"Schwarze Auge, Das Rev 2", "Das Schwarze Auge", "schwarze auge", "Rev 2"},
+		{"Die Hard", "Die Hard", "die hard", ""},
+		{"Zelda Rev A", "Zelda", "zelda", "Rev A"},
+		{"Hello, World", "Hello, World", "hello, world", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			tf := &File{Title: tt.title}
+			if got := tf.DisplayTitle(); got != tt.wantDisplay {
+				t.Errorf("DisplayTitle() = %q, want %q", got, tt.wantDisplay)
+			}
+			if got := tf.SortTitle(); got != tt.wantSort {
+				t.Errorf("SortTitle() = %q, want %q", got, tt.wantSort)
+			}
+			version, ok := tf.Version()
+			if ok != (tt.wantVersion != "") || (ok && version.String() != tt.wantVersion) {
+				t.Errorf("Version() = %q, %v, want %q", version, ok, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"Game v1", "Game v1.0a", "Game v1.1", "Game v1.10", "Game v2"}
+
+	for i := 1; i < len(ordered); i++ {
+		a, _ := (&File{Title: ordered[i-1]}).Version()
+		b, _ := (&File{Title: ordered[i]}).Version()
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("Expected %s to be lower than %s", a, b)
+		}
+	}
+
+	a, _ := (&File{Title: "Game v1.0"}).Version()
+	b, _ := (&File{Title: "Game v1"}).Version()
+	if a.Compare(b) != 0 {
+		t.Errorf("Expected %s to equal %s", a, b)
+	}
+}
+
+func TestPublishers(t *testing.T) {
+	tests := []struct {
+		publisher string
+		want      []string
+	}{
+		{"Hewson Consultants", []string{"Hewson Consultants"}},
+		{"Ocean - Imagine", []string{"Ocean", "Imagine"}},
+		{"-", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.publisher, func(t *testing.T) {
+			tf := &File{Publisher: tt.publisher}
+			if got := tf.Publishers(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Publishers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,44 +6,66 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/climbus/retro-romkit/internal/tree"
+	"github.com/climbus/retro-romkit/pkg/checksum"
 )
 
-const regexMainData = `^(.*?) \((.*?)\)\((.*?)\).*\.(.*)$`
+const regexMainData = `^(.*?)(?: \((demo(?:-[a-z]+)?)\))? \((.*?)\)\((.*?)\).*\.(.*)$`
 const regexFlag = `\[(.*?)\]`
 const regexOption = `\((.*?)\)`
-const languageNames = `(en|fr|de|es|it|ja|zh|ko|pt|ru|nl|pl|sv|no|da|fi|tr|ar|he|hi|th|vi|id|ms|cs|hu|ro|bg|el|uk|hr|sk|sl|lt|lv|et|fa|ur)`
-const regexLanguage = `^` + languageNames + `(-` + languageNames + `)?$`
+const languageNames = `(en|fr|de|es|it|ja|zh|ko|pt|ru|nl|pl|sv|no|da|fi|tr|ar|he|hi|th|vi|id|ms|cs|hu|ro|bg|el|uk|hr|sk|sl|lt|lv|et|fa|ur|sq|bs|sr|is|mn|ne|eo|ga|cy|gu|yi|ca|eu|gd|la)`
+const regexLanguage = `^` + languageNames + `(-` + languageNames + `)*$`
+const regexMultiLanguage = `^M(\d+)$`
 
-const regexRegion = `(Japan|USA|Europe|World|International|Asia|Australia|Brazil|China|Korea|Taiwan)`
 const rootDir = "/"
 
 var (
 	reMainData = regexp.MustCompile(regexMainData)
 	reFlags    = regexp.MustCompile(regexFlag)
 	reOptions  = regexp.MustCompile(regexOption)
-	reRegion   = regexp.MustCompile(regexRegion)
 	reLanguage = regexp.MustCompile(regexLanguage)
+	reMulti    = regexp.MustCompile(regexMultiLanguage)
 )
 
 type Folder struct {
 	Path      string
 	Platform  string
 	FileTypes []string
+	Schemes   []NamingScheme     // Naming schemes to detect, DefaultSchemes when empty
+	Workers   int                // Concurrent file readers when hashing, runtime.NumCPU() when zero
+	Detector  *checksum.Detector // Copier header detector used when hashing, nil to hash files as they are
+	// ReadArchives lists the contents of archives as separate files,
+	// so that archived sets are shown, counted and verified per contained file.
+	ReadArchives bool
 }
 
 type File struct {
 	FileName  string
+	Scheme    string
 	Title     string
-	Date      string
+	Demo      string
+	Date      Date
 	Publisher string
 	Platform  string
 	Format    string
 	Flags     []string
-	Region    string
-	Language  string
+	Dumps     []DumpFlag
+	System    string
+	Video     string
+	Countries []Country
+	Languages []string
+	// MultiLanguage is the language count of an (M<n>) marker, 0 if absent.
+	MultiLanguage int
+	// LanguageInferred is set when Languages were derived from Countries.
+	LanguageInferred bool
+	Copyright        string
+	DevStatus        string
+	Media            *Media
+	MediaLabel       string
 }
 
 type Stats struct {
@@ -51,9 +73,11 @@ type Stats struct {
 	DirectoryCounts map[string]int
 }
 
+// CopyOptions configures PlanCopy.
 type CopyOptions struct {
-	Limit int
-	Unzip bool
+	Output string // Directory to copy the files to
+	Limit  int    // Maximum number of files per target directory, 0 for no limit
+	Unzip  bool   // Extract archives instead of copying them
 }
 type ParseError struct {
 	FileName string
@@ -67,12 +91,20 @@ func ParseFileName(fileName string) (*File, error) {
 	if matches == nil {
 		return nil, errors.New("invalid file name format")
 	}
+	if matches[2] != "" && !isDemoType(matches[2]) {
+		return nil, fmt.Errorf("unknown demo type '%s'", matches[2])
+	}
+	date, err := ParseDate(strings.TrimSpace(matches[3]))
+	if err != nil {
+		return nil, err
+	}
 	tf := &File{
 		FileName:  fileName,
 		Title:     strings.TrimSpace(matches[1]),
-		Date:      strings.TrimSpace(matches[2]),
-		Publisher: strings.TrimSpace(matches[3]),
-		Format:    strings.TrimSpace(matches[4]),
+		Demo:      matches[2],
+		Date:      date,
+		Publisher: strings.TrimSpace(matches[4]),
+		Format:    strings.TrimSpace(matches[5]),
 	}
 
 	rest := tf.extractRestPartOfName()
@@ -80,21 +112,17 @@ func ParseFileName(fileName string) (*File, error) {
 	flagsRes := reFlags.FindAllStringSubmatch(rest, -1)
 	flags := extractValues(flagsRes)
 	tf.Flags = flags
+	tf.Dumps = parseDumpFlags(flags)
 
 	optionsRes := reOptions.FindAllStringSubmatch(rest, -1)
 	options := extractValues(optionsRes)
 
+	last := fieldNone
 	for _, opt := range options {
-		opt = strings.TrimSpace(opt)
-		if tf.Region == "" && reRegion.MatchString(opt) {
-			tf.Region = opt
-		} else if tf.Language == "" && reLanguage.MatchString(opt) {
-			tf.Language = opt
-		}
+		last = tf.applyOption(opt, last)
 	}
+	tf.inferLanguages()
 
-	// fmt.Println("Rest of the file name:", rest)
-	// fmt.Println("Options", options)
 	return tf, nil
 }
 
@@ -110,24 +138,55 @@ func Create(path, platformName string) *Folder {
 		os.Exit(1)
 	}
 
-	fmt.Print("Platform Name: ")
-	fmt.Println(platformName)
+	fmt.Fprintf(os.Stderr, "Platform Name: %s\n", platformName)
 
 	return &Folder{
 		Path:      path,
 		Platform:  platformName,
 		FileTypes: platform.FileTypes,
+		Detector:  platform.Detector,
 	}
 }
 
-// GetFileTree returns a channel of tree entries for the given path
+// GetFileTree returns a channel of tree entries for the given path. With
+// ReadArchives set, archives are sent as directories holding their
+// contained files.
 func (tosecFolder *Folder) GetFileTree() (<-chan tree.Entry, <-chan error) {
 	entries := make(chan tree.Entry, 100)
 	errCh := make(chan error, 1)
 
 	go func() {
 		defer close(errCh)
-		if err := tree.Walk(tosecFolder.Path, tosecFolder.fileTypesWithArchives(), entries); err != nil {
+		opts := tree.Options{FileTypes: tosecFolder.fileTypesWithArchives(), Archives: tosecFolder.ReadArchives}
+		if err := tree.WalkWithOptions(tosecFolder.Path, opts, entries); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return entries, errCh
+}
+
+// GetHashedFileTree returns a channel of tree entries with the checksums of
+// each file attached. Archives are hashed per contained file, and files
+// with a header recognized by the folder's Detector are also hashed without
+// it. Entries are not guaranteed to arrive in walk order. Files which
+// cannot be hashed are sent with Err set; only errors walking the folder
+// are sent to the error channel.
+func (tosecFolder *Folder) GetHashedFileTree() (<-chan tree.Entry, <-chan error) {
+	walked, walkErrCh := tosecFolder.GetFileTree()
+	entries := make(chan tree.Entry, 100)
+	errCh := make(chan error, 1)
+
+	workers := tosecFolder.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+
+	go func() {
+		defer close(errCh)
+		// Hash errors are reported per entry
+		_ = tree.Hash(walked, checksum.Hasher{Detector: tosecFolder.Detector}, workers, entries)
+		if err := <-walkErrCh; err != nil {
 			errCh <- err
 		}
 	}()
@@ -145,7 +204,7 @@ func (tosecFolder *Folder) GetFiles() ([]File, error) {
 
 	for entry := range entries {
 		if !entry.IsDir {
-			tf, err := ParseFileName(entry.Name)
+			tf, err := ParseWithSchemes(entry.Name, tosecFolder.namingSchemes())
 			if err != nil {
 				parseErrors = append(parseErrors, ParseError{
 					FileName: entry.Name,
@@ -230,20 +289,80 @@ func (tosecFolder *Folder) GetStats() (Stats, error) {
 	return stats, nil
 }
 
-func (tosecFolder *Folder) BuildTree(_ CopyOptions) []tree.Entry {
-	entries := make([]tree.Entry, 0)
+// applyOption assigns an option to the first unset field it is valid for.
+// Fields are tried in the order defined by the TOSEC naming convention.
+// prev is the field of the preceding option, as a media label is only
+// accepted directly after the media field. It returns the field the option
+// was assigned to, or fieldNone.
+func (tf *File) applyOption(opt string, prev optionField) optionField {
+	if field := tf.applyMachineOption(opt); field != fieldNone {
+		return field
+	}
+	if field := tf.applyLocaleOption(opt); field != fieldNone {
+		return field
+	}
+	return tf.applyStatusOption(opt, prev)
+}
 
-	files, err := tosecFolder.GetFiles()
+func (tf *File) applyMachineOption(opt string) optionField {
+	switch {
+	case tf.System == "" && isSystem(opt):
+		tf.System = opt
+		return fieldSystem
+	case tf.Video == "" && isVideoMode(opt):
+		tf.Video = opt
+		return fieldVideo
+	}
+	return fieldNone
+}
 
-	if err != nil {
-		fmt.Println("Error retrieving files:", err)
+func (tf *File) applyLocaleOption(opt string) optionField {
+	switch {
+	case tf.Countries == nil && isCountryList(opt):
+		tf.Countries, _ = ParseCountries(opt)
+		return fieldCountry
+	case !tf.hasLanguageField() && reLanguage.MatchString(opt):
+		tf.Languages = strings.Split(opt, "-")
+		return fieldLanguage
+	case !tf.hasLanguageField() && reMulti.MatchString(opt):
+		tf.MultiLanguage, _ = strconv.Atoi(reMulti.FindStringSubmatch(opt)[1])
+		return fieldLanguage
 	}
+	return fieldNone
+}
 
-	for _, file := range files {
-		fmt.Printf("Processing file: %s (%s) - %s - r:%s l:%s : %s\n", file.Title, file.Date, file.Publisher, file.Region, file.Language, file.FileName)
+func (tf *File) applyStatusOption(opt string, prev optionField) optionField {
+	switch {
+	case tf.Copyright == "" && isCopyrightStatus(opt):
+		tf.Copyright = opt
+		return fieldCopyright
+	case tf.DevStatus == "" && isDevelopmentStatus(opt):
+		tf.DevStatus = opt
+		return fieldDevStatus
+	case tf.Media == nil && isMedia(opt):
+		tf.Media, _ = ParseMedia(opt)
+		return fieldMedia
+	case prev == fieldMedia && isMedia(opt):
+		if tf.addMediaSide(opt) {
+			return fieldMedia
+		}
+		return fieldNone // A second medium is not a label
+	case prev == fieldMedia && tf.MediaLabel == "":
+		tf.MediaLabel = opt
+		return fieldMediaLabel
 	}
+	return fieldNone
+}
 
-	return entries
+// addMediaSide merges a second media field holding only a side, as in
+// "(Disk 1 of 2)(Side A)", into the media. opt must be a valid media field.
+func (tf *File) addMediaSide(opt string) bool {
+	media, _ := ParseMedia(opt)
+	if media.Number > 0 || tf.Media.Side != "" {
+		return false
+	}
+	tf.Media.Side = media.Side
+	return true
 }
 
 func (tf *File) extractRestPartOfName() string {
@@ -267,6 +386,16 @@ func (tf *File) extractRestPartOfName() string {
 	return rest
 }
 
+func parseDumpFlags(flags []string) []DumpFlag {
+	dumps := make([]DumpFlag, 0)
+	for _, flag := range flags {
+		if df, ok := ParseDumpFlag(flag); ok {
+			dumps = append(dumps, df)
+		}
+	}
+	return dumps
+}
+
 func extractValues(elements [][]string) []string {
 	values := make([]string, 0)
 	for _, val := range elements {
@@ -281,7 +410,7 @@ func (tosecFolder *Folder) fileTypesWithArchives() []string {
 	}
 	// Add common archive formats to the file types
 	// TODO: Consider making this configurable or extensible
-	archiveTypes := []string{"zip", "rar", "7z", "tar", "gz", "bz2"}
+	archiveTypes := []string{"zip", "rar", "7z", "tar", "gz", "tgz", "bz2"}
 	fileTypes := make([]string, len(tosecFolder.FileTypes)+len(archiveTypes))
 	copy(fileTypes, tosecFolder.FileTypes)
 	copy(fileTypes[len(tosecFolder.FileTypes):], archiveTypes)
@@ -133,7 +133,7 @@ func TestGetFiles(t *testing.T) {
 
 	testFiles := []string{
 		"Game One (1990)(Publisher A).zip",
-		"Game Two (1991)(Publisher B)(Europe)(en).zip",
+		"Game Two (1991)(Publisher B)(EU)(en).zip",
 		"Game Three (1992)(Publisher C)[a].zip",
 		"InvalidFileName.txt",
 		"subdir/Game Four (1993)(Publisher D).zip",
@@ -167,7 +167,7 @@ func TestGetFiles(t *testing.T) {
 				if file.Title == "" {
 					t.Errorf("File has empty title: %+v", file)
 				}
-				if file.Date == "" {
+				if file.Date.IsZero() {
 					t.Errorf("File has empty date: %+v", file)
 				}
 				if file.Publisher == "" {
@@ -280,27 +280,27 @@ func TestParseFileName(t *testing.T) {
 			&File{
 				FileName:  "Zynaps (1987)(Hewson Consultants).zip",
 				Title:     "Zynaps",
-				Date:      "1987",
+				Date:      Date{year: "1987"},
 				Publisher: "Hewson Consultants",
-				Region:    "",
-				Language:  "",
 				Format:    "zip",
 				Flags:     []string{},
+				Dumps:     []DumpFlag{},
 			},
 			false,
 		},
 		{
 			"Test filename with region and language",
-			"Zynaps (1987)(Hewson Consultants)(Europe)(en).zip",
+			"Zynaps (1987)(Hewson Consultants)(EU)(en).zip",
 			&File{
-				FileName:  "Zynaps (1987)(Hewson Consultants)(Europe)(en).zip",
+				FileName:  "Zynaps (1987)(Hewson Consultants)(EU)(en).zip",
 				Title:     "Zynaps",
-				Date:      "1987",
+				Date:      Date{year: "1987"},
 				Publisher: "Hewson Consultants",
-				Region:    "Europe",
-				Language:  "en",
+				Countries: []Country{{"EU", "Europe", RegionEurope, ""}},
+				Languages: []string{"en"},
 				Format:    "zip",
 				Flags:     []string{},
+				Dumps:     []DumpFlag{},
 			},
 			false,
 		},
@@ -310,13 +310,71 @@ func TestParseFileName(t *testing.T) {
 			&File{
 				FileName:  "Zynaps (1987)(Hewson Consultants)[a][Aka kota].zip",
 				Title:     "Zynaps",
-				Date:      "1987",
+				Date:      Date{year: "1987"},
 				Publisher: "Hewson Consultants",
 				Format:    "zip",
 				Flags:     []string{"a", "Aka kota"},
+				Dumps:     []DumpFlag{{Kind: DumpAlternate, Raw: "a"}},
 			},
 			false,
 		},
+		{
+			"Test filename with multiple countries",
+			"Zynaps (1987)(Hewson Consultants)(GB-FR).zip",
+			&File{
+				FileName:  "Zynaps (1987)(Hewson Consultants)(GB-FR).zip",
+				Title:     "Zynaps",
+				Date:      Date{year: "1987"},
+				Publisher: "Hewson Consultants",
+				Countries: []Country{{"GB", "United Kingdom", RegionEurope, "en"}, {"FR", "France", RegionEurope, "fr"}},
+				Languages: []string{"en", "fr"},
+				Format:    "zip",
+				Flags:     []string{},
+				Dumps:     []DumpFlag{},
+
+				LanguageInferred: true,
+			},
+			false,
+		},
+		{
+			"Test filename with demo, system and video",
+			"Agony (demo-playable) (1992)(Psygnosis)(A500)(PAL).adf",
+			&File{
+				FileName:  "Agony (demo-playable) (1992)(Psygnosis)(A500)(PAL).adf",
+				Title:     "Agony",
+				Demo:      "demo-playable",
+				Date:      Date{year: "1992"},
+				Publisher: "Psygnosis",
+				System:    "A500",
+				Video:     "PAL",
+				Format:    "adf",
+				Flags:     []string{},
+				Dumps:     []DumpFlag{},
+			},
+			false,
+		},
+		{
+			"Test filename with combined systems",
+			"Zool (1993)(Gremlin)(A500-A600)(NTSC).adf",
+			&File{
+				FileName:  "Zool (1993)(Gremlin)(A500-A600)(NTSC).adf",
+				Title:     "Zool",
+				Date:      Date{year: "1993"},
+				Publisher: "Gremlin",
+				System:    "A500-A600",
+				Video:     "NTSC",
+				Format:    "adf",
+				Flags:     []string{},
+				Dumps:     []DumpFlag{},
+			},
+			false,
+		},
+		{
+			"Test unknown demo type",
+			"Agony (demo-unknown) (1992)(Psygnosis).adf",
+			nil,
+			true,
+		},
 		{
 			"Test bad filename",
 			"InvalidFileName.txt",
@@ -345,3 +403,31 @@ func TestParseFileName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHashedFileTree(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestFiles(t, []string{"Zynaps (1987)(Hewson).d64", "subdir/Uridium (1986)(Hewson).d64"}, tmpDir)
+
+	tosecFolder := Create(tmpDir, "c64")
+	tosecFolder.Workers = 2
+	entries, errCh := tosecFolder.GetHashedFileTree()
+
+	files := 0
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		files++
+		if len(entry.Sums) != 1 || entry.Sums[0].CRC32 != "00000000" {
+			t.Errorf("entry %q checksums = %+v", entry.Name, entry.Sums)
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("GetHashedFileTree() error = %v", err)
+	}
+	if files != 2 {
+		t.Errorf("GetHashedFileTree() returned %d files, want 2", files)
+	}
+}
@@ -0,0 +1,217 @@
+package tosec
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/climbus/retro-romkit/pkg/archive"
+	"github.com/climbus/retro-romkit/pkg/checksum"
+	"github.com/climbus/retro-romkit/pkg/dat"
+)
+
+// VerifyStatus is the outcome of verifying a single file or DAT entry.
+type VerifyStatus string
+
+const (
+	// StatusHave marks a file present under its DAT name.
+	StatusHave VerifyStatus = "have"
+	// StatusMissing marks a DAT entry with no matching file.
+	StatusMissing VerifyStatus = "missing"
+	// StatusUnknown marks a file not described by any DAT.
+	StatusUnknown VerifyStatus = "unknown"
+	// StatusBadName marks a file matching a DAT entry under a different name.
+	StatusBadName VerifyStatus = "bad-name"
+)
+
+// VerifyResult describes the status of one file or DAT entry. Path is
+// relative to the folder and empty for missing entries; Dat, Game and Rom
+// are empty for unknown files. Dat is the name from the DAT header. Error
+// is set for unknown files which could not be read.
+type VerifyResult struct {
+	Status VerifyStatus `json:"status"`
+	Path   string       `json:"path,omitempty"`
+	Dat    string       `json:"dat,omitempty"`
+	Game   string       `json:"game,omitempty"`
+	Rom    string       `json:"rom,omitempty"`
+	Error  string       `json:"error,omitempty"`
+
+	datafile *dat.Datafile // DAT of the matched rom, as names may repeat
+}
+
+// VerifyReport lists the results of verifying a folder against DATs.
+// Results for scanned files come first, followed by missing entries in DAT order.
+type VerifyReport struct {
+	Results []VerifyResult
+}
+
+type datEntry struct {
+	datafile *dat.Datafile
+	game     string
+	rom      string
+}
+
+// Count returns the number of results with the given status.
+func (r VerifyReport) Count(status VerifyStatus) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Verify compares the files found by GetFileTree against the roms of the
+// DATs. A file whose name differs from a DAT rom only by case or by
+// repairable naming mistakes is reported as bad-name. A file is unknown
+// only if none of the DATs describes it.
+func (tosecFolder *Folder) Verify(datafiles ...*dat.Datafile) (VerifyReport, error) {
+	byName := make(map[string][]datEntry)
+	byFoldedName := make(map[string][]datEntry)
+	for _, datafile := range datafiles {
+		for _, game := range datafile.Games {
+			for _, rom := range game.Roms {
+				entry := datEntry{datafile: datafile, game: game.Name, rom: rom.Name}
+				// Files are compared by name, whatever folder the DAT puts them in
+				name := path.Base(rom.Name)
+				byName[name] = append(byName[name], entry)
+				folded := strings.ToLower(name)
+				byFoldedName[folded] = append(byFoldedName[folded], entry)
+			}
+		}
+	}
+
+	var report VerifyReport
+	found := make(map[datEntry]bool)
+
+	entries, errCh := tosecFolder.GetFileTree()
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		result := VerifyResult{Status: StatusUnknown, Path: filepath.Join(entry.Folder, entry.Name)}
+		matches, ok := byName[entry.Name]
+		if ok {
+			result.Status = StatusHave
+		} else if matches, ok = matchBadName(entry.Name, byName, byFoldedName); ok {
+			result.Status = StatusBadName
+		}
+		if ok {
+			result.setRom(matches[0].datafile, matches[0].game, matches[0].rom)
+			for _, match := range matches {
+				found[match] = true
+			}
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if err := <-errCh; err != nil {
+		return VerifyReport{}, err
+	}
+
+	report.appendMissing(datafiles, found)
+	return report, nil
+}
+
+// VerifyChecksums compares the contents of the files, hashed by
+// GetHashedFileTree, against the roms of the DATs. A file matching a rom by
+// checksum is reported as have when it carries the rom's name and as
+// bad-name otherwise. Members of archives are verified one by one. Files
+// which cannot be hashed are reported as unknown, with Error set.
+func (tosecFolder *Folder) VerifyChecksums(datafiles ...*dat.Datafile) (VerifyReport, error) {
+	indexes := make([]*dat.Index, len(datafiles))
+	for i, datafile := range datafiles {
+		indexes[i] = dat.NewIndex(datafile)
+	}
+
+	var report VerifyReport
+	found := make(map[datEntry]bool)
+
+	entries, errCh := tosecFolder.GetHashedFileTree()
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if entry.Err != nil {
+			report.Results = append(report.Results, VerifyResult{
+				Status: StatusUnknown,
+				Path:   filepath.Join(entry.Folder, entry.Name),
+				Error:  entry.Err.Error(),
+			})
+			continue
+		}
+		for _, sums := range entry.Sums {
+			result := VerifyResult{Status: StatusUnknown, Path: filepath.Join(entry.Folder, entry.Name)}
+			if entry.Archive == "" && archive.IsArchive(entry.Path) {
+				result.Path = filepath.Join(result.Path, sums.Name)
+			}
+			for i, idx := range indexes {
+				for _, match := range idx.Lookup(sums) {
+					found[datEntry{datafile: datafiles[i], game: match.Game.Name, rom: match.Rom.Name}] = true
+					result.applyMatch(datafiles[i], match, sums)
+				}
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return VerifyReport{}, err
+	}
+
+	report.appendMissing(datafiles, found)
+	return report, nil
+}
+
+// applyMatch records a DAT rom matching the file by checksum. A match under
+// the file's own name takes precedence over earlier bad-name matches.
+func (result *VerifyResult) applyMatch(datafile *dat.Datafile, match dat.Match, sums checksum.Sums) {
+	if result.Status == StatusHave {
+		return
+	}
+	status := StatusBadName
+	if path.Base(match.Rom.Name) == path.Base(sums.Name) {
+		status = StatusHave
+	}
+	if result.Status == StatusUnknown || status == StatusHave {
+		result.Status = status
+		result.setRom(datafile, match.Game.Name, match.Rom.Name)
+	}
+}
+
+func (result *VerifyResult) setRom(datafile *dat.Datafile, game, rom string) {
+	result.datafile = datafile
+	result.Dat, result.Game, result.Rom = datafile.Header.Name, game, rom
+}
+
+// appendMissing adds a missing result for every DAT rom which was not found.
+func (r *VerifyReport) appendMissing(datafiles []*dat.Datafile, found map[datEntry]bool) {
+	for _, datafile := range datafiles {
+		for _, game := range datafile.Games {
+			for _, rom := range game.Roms {
+				if !found[datEntry{datafile: datafile, game: game.Name, rom: rom.Name}] {
+					result := VerifyResult{Status: StatusMissing}
+					result.setRom(datafile, game.Name, rom.Name)
+					r.Results = append(r.Results, result)
+				}
+			}
+		}
+	}
+}
+
+func matchBadName(fileName string, byName, byFoldedName map[string][]datEntry) ([]datEntry, bool) {
+	candidates := []string{fileName}
+	if repaired, err := RepairFileName(fileName); err == nil {
+		candidates = append(candidates, repaired)
+	}
+	for _, candidate := range candidates {
+		if matches, ok := byName[candidate]; ok {
+			return matches, true
+		}
+		if matches, ok := byFoldedName[strings.ToLower(candidate)]; ok {
+			return matches, true
+		}
+	}
+	return nil, false
+}
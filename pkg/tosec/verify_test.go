@@ -0,0 +1,213 @@
+package tosec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/climbus/retro-romkit/pkg/dat"
+	"github.com/climbus/retro-romkit/testutils"
+)
+
+func TestVerify(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testFiles := []string{
+		"Zynaps (1987)(Hewson).d64",
+		"uridium (1986)(hewson).d64",
+		"Paradroid (1985)(Hewson)[a][cr].d64",
+		"Unrelated (1990)(Nobody).d64",
+		"Nebulus (1987)(Hewson)/Nebulus (1987)(Hewson)(Disk 1 of 2).d64",
+	}
+	testutils.CreateTestFiles(t, testFiles, tmpDir)
+
+	datafile := &dat.Datafile{
+		Games: []dat.Game{
+			{Name: "Zynaps (1987)(Hewson)", Roms: []dat.Rom{{Name: "Zynaps (1987)(Hewson).d64"}}},
+			{Name: "Nebulus (1987)(Hewson)", Roms: []dat.Rom{{Name: "Disks/Nebulus (1987)(Hewson)(Disk 1 of 2).d64"}}},
+			{Name: "Uridium (1986)(Hewson)", Roms: []dat.Rom{{Name: "Uridium (1986)(Hewson).d64"}}},
+			{Name: "Paradroid (1985)(Hewson)[cr][a]", Roms: []dat.Rom{{Name: "Paradroid (1985)(Hewson)[cr][a].d64"}}},
+			{Name: "Exolon (1987)(Hewson)", Roms: []dat.Rom{{Name: "Exolon (1987)(Hewson).d64"}}},
+		},
+	}
+
+	report, err := Create(tmpDir, "c64").Verify(datafile)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	want := map[string]VerifyResult{
+		"Zynaps (1987)(Hewson).d64":           {Status: StatusHave, Rom: "Zynaps (1987)(Hewson).d64"},
+		"uridium (1986)(hewson).d64":          {Status: StatusBadName, Rom: "Uridium (1986)(Hewson).d64"},
+		"Paradroid (1985)(Hewson)[a][cr].d64": {Status: StatusBadName, Rom: "Paradroid (1985)(Hewson)[cr][a].d64"},
+		"Unrelated (1990)(Nobody).d64":        {Status: StatusUnknown},
+		filepath.Join("Nebulus (1987)(Hewson)", "Nebulus (1987)(Hewson)(Disk 1 of 2).d64"): {Status: StatusHave, Rom: "Disks/Nebulus (1987)(Hewson)(Disk 1 of 2).d64"},
+	}
+	for _, result := range report.Results {
+		if result.Status == StatusMissing {
+			if result.Rom != "Exolon (1987)(Hewson).d64" {
+				t.Errorf("unexpected missing rom %q", result.Rom)
+			}
+			continue
+		}
+		w, ok := want[result.Path]
+		if !ok {
+			t.Errorf("unexpected result for %q", result.Path)
+			continue
+		}
+		if result.Status != w.Status || result.Rom != w.Rom {
+			t.Errorf("Verify() %q = %s %q, want %s %q", result.Path, result.Status, result.Rom, w.Status, w.Rom)
+		}
+	}
+
+	counts := map[VerifyStatus]int{StatusHave: 2, StatusBadName: 2, StatusUnknown: 1, StatusMissing: 1}
+	for status, count := range counts {
+		if got := report.Count(status); got != count {
+			t.Errorf("Count(%s) = %d, want %d", status, got, count)
+		}
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	rom := strings.Repeat("\xea", 32)
+	header := "NES\x1a" + strings.Repeat("\x00", 12)
+	writeTestFile(t, filepath.Join(tmpDir, "Super Mario Bros. (World).nes"), header+rom)
+	writeTestFile(t, filepath.Join(tmpDir, "smb.nes"), rom)
+	writeTestFile(t, filepath.Join(tmpDir, "Zelda (World).nes"), "zelda")
+
+	datafile := &dat.Datafile{
+		Header: dat.Header{Name: "Nintendo - NES"},
+		Games: []dat.Game{
+			{Name: "Super Mario Bros. (World)", Roms: []dat.Rom{{Name: "Super Mario Bros. (World).nes", Size: 32, CRC: crc(rom)}}},
+			{Name: "Duck Hunt (World)", Roms: []dat.Rom{{Name: "Duck Hunt (World).nes", Size: 4, CRC: crc("duck")}}},
+		},
+	}
+
+	report, err := Create(tmpDir, "nes").VerifyChecksums(datafile)
+	if err != nil {
+		t.Fatalf("VerifyChecksums() error = %v", err)
+	}
+
+	want := map[string]VerifyStatus{
+		"Super Mario Bros. (World).nes": StatusHave,
+		"smb.nes":                       StatusBadName,
+		"Zelda (World).nes":             StatusUnknown,
+	}
+	for _, result := range report.Results {
+		if result.Status == StatusMissing {
+			if result.Rom != "Duck Hunt (World).nes" {
+				t.Errorf("unexpected missing rom %q", result.Rom)
+			}
+			continue
+		}
+		if result.Status != want[result.Path] {
+			t.Errorf("VerifyChecksums() %q = %s, want %s", result.Path, result.Status, want[result.Path])
+		}
+	}
+	if got := report.Count(StatusMissing); got != 1 {
+		t.Errorf("Count(missing) = %d, want 1", got)
+	}
+}
+
+func TestVerifyChecksumsSharedContents(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "Uridium (1986)(Hewson).d64"), "uridium")
+
+	datafile := &dat.Datafile{
+		Header: dat.Header{Name: "Commodore C64 - Games"},
+		Games: []dat.Game{
+			{Name: "Hewson Hits (1987)(Hewson)", Roms: []dat.Rom{{Name: "Hewson Hits (1987)(Hewson).d64", Size: 7, CRC: crc("uridium")}}},
+			{Name: "Uridium (1986)(Hewson)", Roms: []dat.Rom{{Name: "Uridium (1986)(Hewson).d64", Size: 7, CRC: crc("uridium")}}},
+		},
+	}
+
+	report, err := Create(tmpDir, "c64").VerifyChecksums(datafile)
+	if err != nil {
+		t.Fatalf("VerifyChecksums() error = %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Status != StatusHave || report.Results[0].Rom != "Uridium (1986)(Hewson).d64" {
+		t.Errorf("VerifyChecksums() = %+v, want the file reported as have and no missing roms", report.Results)
+	}
+}
+
+func TestVerifyChecksumsUnreadableFiles(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "Zynaps (1987)(Hewson).d64"), "hello")
+	writeTestFile(t, filepath.Join(tmpDir, "broken.zip"), "not a zip")
+	datafile := &dat.Datafile{
+		Games: []dat.Game{{Name: "Zynaps", Roms: []dat.Rom{{Name: "Zynaps (1987)(Hewson).d64", Size: 5, CRC: crc("hello")}}}},
+	}
+
+	report, err := Create(tmpDir, "c64").VerifyChecksums(datafile)
+	if err != nil {
+		t.Fatalf("VerifyChecksums() error = %v", err)
+	}
+	if report.Count(StatusHave) != 1 {
+		t.Errorf("Count(have) = %d, want 1", report.Count(StatusHave))
+	}
+	var broken *VerifyResult
+	for i := range report.Results {
+		if report.Results[i].Path == "broken.zip" {
+			broken = &report.Results[i]
+		}
+	}
+	if broken == nil || broken.Status != StatusUnknown || broken.Error == "" {
+		t.Errorf("VerifyChecksums() = %+v, want broken.zip reported as unknown with an error", report.Results)
+	}
+}
+
+func TestVerifyChecksumsReadArchives(t *testing.T) {
+	tmpDir := testutils.CreateTempDir(t)
+	defer os.RemoveAll(tmpDir)
+
+	testutils.CreateTestZip(t, filepath.Join(tmpDir, "Zynaps.zip"), map[string]string{
+		"Zynaps (1987)(Hewson)(Disk 1 of 2).d64": "disk1",
+		"zynaps2.d64":                            "disk2",
+	})
+
+	datafile := &dat.Datafile{
+		Games: []dat.Game{
+			{Name: "Zynaps (1987)(Hewson)", Roms: []dat.Rom{
+				{Name: "Zynaps (1987)(Hewson)(Disk 1 of 2).d64", Size: 5, CRC: crc("disk1")},
+				{Name: "Zynaps (1987)(Hewson)(Disk 2 of 2).d64", Size: 5, CRC: crc("disk2")},
+			}},
+		},
+	}
+
+	folder := Create(tmpDir, "c64")
+	folder.ReadArchives = true
+	report, err := folder.VerifyChecksums(datafile)
+	if err != nil {
+		t.Fatalf("VerifyChecksums() error = %v", err)
+	}
+
+	want := map[string]VerifyStatus{
+		filepath.Join("Zynaps.zip", "Zynaps (1987)(Hewson)(Disk 1 of 2).d64"): StatusHave,
+		filepath.Join("Zynaps.zip", "zynaps2.d64"):                            StatusBadName,
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("VerifyChecksums() = %+v, want %d results", report.Results, len(want))
+	}
+	for _, result := range report.Results {
+		if result.Status != want[result.Path] {
+			t.Errorf("VerifyChecksums() %q = %s, want %s", result.Path, result.Status, want[result.Path])
+		}
+	}
+
+	stats, err := folder.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if stats.TotalFiles != 2 || stats.DirectoryCounts["Zynaps.zip"] != 2 {
+		t.Errorf("GetStats() = %+v, want 2 files in Zynaps.zip", stats)
+	}
+}
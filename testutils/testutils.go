@@ -2,8 +2,15 @@
 package testutils
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +37,85 @@ func CreateTempDir(t *testing.T) string {
 	}
 	return tmpDir
 }
+
+// CreateTestZip writes a zip archive holding the files, mapping member names
+// to their contents. Names ending with a slash are stored as directories.
+func CreateTestZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f := createArchiveFile(t, path)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s to %s: %v", name, path, err)
+		}
+		if _, err := entry.Write([]byte(files[name])); err != nil {
+			t.Fatalf("Failed to write %s to %s: %v", name, path, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close %s: %v", path, err)
+	}
+}
+
+// CreateTestTar writes a tar archive holding the files like CreateTestZip,
+// gzip compressed when compress is set.
+func CreateTestTar(t *testing.T, path string, files map[string]string, compress bool) {
+	t.Helper()
+	f := createArchiveFile(t, path)
+	defer f.Close()
+
+	var out io.Writer = f
+	if compress {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		out = gz
+	}
+	w := tar.NewWriter(out)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		header := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(files[name]))}
+		if strings.HasSuffix(name, "/") {
+			header = &tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755}
+		}
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to add %s to %s: %v", name, path, err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatalf("Failed to write %s to %s: %v", name, path, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close %s: %v", path, err)
+	}
+}
+
+// CreateTestGzip writes a gzip file holding content, with name stored in
+// its header unless empty.
+func CreateTestGzip(t *testing.T, path, name, content string) {
+	t.Helper()
+	f := createArchiveFile(t, path)
+	defer f.Close()
+
+	w := gzip.NewWriter(f)
+	w.Name = name
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close %s: %v", path, err)
+	}
+}
+
+func createArchiveFile(t *testing.T, path string) *os.File {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir for %s: %v", path, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file %s: %v", path, err)
+	}
+	return f
+}